@@ -7,9 +7,9 @@ toolchain go1.24.6
 require (
 	github.com/go-chi/chi/v5 v5.2.2
 	github.com/go-chi/cors v1.2.2
+	github.com/google/uuid v1.6.0
 	github.com/jackc/pgx/v5 v5.7.5
 	github.com/joho/godotenv v1.5.1
-	github.com/google/uuid v1.6.0
 )
 
 require (
@@ -20,3 +20,7 @@ require (
 	golang.org/x/sync v0.13.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
+
+require stock/common v0.0.0
+
+replace stock/common => ../stock-common
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -8,11 +10,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/joho/godotenv"
 
+	"stock/backend/pkg/engine"
 	"stock/backend/pkg/handlers"
+	"stock/common/db"
 
 	cp_middleware "stock/backend/pkg/middleware"
 
@@ -23,6 +28,20 @@ func main() {
 
 	godotenv.Overload()
 
+	// Un solo pool para todo el proceso; se configura con DB_MIN_CONNS, DB_MAX_CONNS y DB_HEALTH_CHECK_PERIOD
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	pool, err := db.NewPool(ctx, db.ConfigFromEnv())
+	cancel()
+	if err != nil {
+		log.Fatalf("no se pudo conectar a la base: %v", err)
+	}
+	defer pool.Close()
+	engine.UsePool(pool)
+
+	if err := engine.CheckSchemaVersion(); err != nil {
+		log.Fatalf("esquema incompatible: %v", err)
+	}
+
 	r := chi.NewRouter()
 	r.Use(chi_middleware.Logger)
 	r.Use(cp_middleware.ApplyCorsHandler())
@@ -31,6 +50,13 @@ func main() {
 		r.Route("/api", func(r chi.Router) {
 			r.Get("/stocks/list", handlers.GetStocksHandler)
 			r.Get("/stocks/recommendations", handlers.GetBasicRecommendationsHandler)
+			r.Get("/stocks/facets", handlers.GetStockFacetsHandler)
+			r.Get("/stocks/{code}", handlers.GetStockHandler)
+			r.Get("/tickers/consensus", handlers.GetConsensusListHandler)
+			r.Get("/tickers/{ticker}/history", handlers.GetTickerHistoryHandler)
+			r.Get("/tickers/{ticker}/consensus", handlers.GetTickerConsensusHandler)
+			r.Get("/brokerages", handlers.GetBrokeragesHandler)
+			r.Get("/brokerages/{name}", handlers.GetBrokerageHandler)
 		})
 	})
 
@@ -41,37 +67,61 @@ func main() {
 func startSever(r *chi.Mux) {
 	listenBy := os.Getenv("LISTENER")
 	port := os.Getenv("PORT")
+	server := &http.Server{Handler: r}
+
+	var listener net.Listener
+	var err error
 	if listenBy == "SOCKET" {
 		if port == "" {
 			port = "/tmp/stock-backend.sock"
 		}
-		setupSignalHandler(port)
-		listener, err := net.Listen("unix", port)
+		listener, err = net.Listen("unix", port)
 		if err != nil {
 			panic(err)
 		}
-		defer listener.Close()
 		log.Printf("[Escuchando en archvivo %s]", port)
-		http.Serve(listener, r)
 	} else {
 		if port == "" {
 			port = "3000"
 		}
 		host := os.Getenv("HOST")
+		listener, err = net.Listen("tcp", fmt.Sprintf("%s:%s", host, port))
+		if err != nil {
+			panic(err)
+		}
 		log.Printf("[Escuchando en host:port (%s:%s)]", host, port)
-		http.ListenAndServe(fmt.Sprintf("%s:%s", host, port), r)
+	}
+
+	done := setupSignalHandler(server)
+	if err := server.Serve(listener); errors.Is(err, http.ErrServerClosed) {
+		<-done
+	} else {
+		log.Printf("error del servidor: %v", err)
+	}
+
+	// Al cerrar el listener unix el archivo de socket se elimina; por si acaso se intenta de nuevo
+	if listenBy == "SOCKET" {
+		if err := os.Remove(port); err != nil && !errors.Is(err, os.ErrNotExist) {
+			fmt.Println("Error al eliminar el archivo de socket:", err)
+		}
 	}
 }
 
-func setupSignalHandler(socketPath string) {
+// setupSignalHandler detiene el servidor ante una interrupción o SIGTERM, esperando
+// a que terminen las peticiones en curso; el canal se cierra cuando el apagado termina
+func setupSignalHandler(server *http.Server) <-chan struct{} {
+	done := make(chan struct{})
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
+		defer close(done)
 		<-c
-		// Eliminar el archivo de socket cuando se recibe una señal de interrupción o SIGTERM
-		if err := os.Remove(socketPath); err != nil {
-			fmt.Println("Error al eliminar el archivo de socket:", err)
+		log.Println("Deteniendo el servidor...")
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("error deteniendo el servidor: %v", err)
 		}
-		os.Exit(1)
 	}()
+	return done
 }
@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"stock/common/model"
+)
+
+// BrokerageStats resume la actividad de un brokerage en la tabla stocks.
+// Upgrades y downgrades siguen el campo calculado direction; el ratio es null sin downgrades.
+type BrokerageStats struct {
+	Name                  string     `json:"name"`
+	Actions               int        `json:"actions"`
+	Upgrades              int        `json:"upgrades"`
+	Downgrades            int        `json:"downgrades"`
+	UpgradeDowngradeRatio *float64   `json:"upgrade_downgrade_ratio"`
+	AvgTargetChangePct    *float64   `json:"avg_target_change_pct"`
+	Tickers               int        `json:"tickers"`
+	LastRecordTime        *time.Time `json:"last_record_time"`
+}
+
+type BrokeragePeriod struct {
+	Start      time.Time `json:"start"`
+	Actions    int       `json:"actions"`
+	Upgrades   int       `json:"upgrades"`
+	Downgrades int       `json:"downgrades"`
+}
+
+type BrokerageTicker struct {
+	Ticker         string     `json:"ticker"`
+	Company        *string    `json:"company"`
+	Actions        int        `json:"actions"`
+	LastRecordTime *time.Time `json:"last_record_time"`
+}
+
+type BrokerageDetail struct {
+	BrokerageStats
+	Period     string            `json:"period"`
+	Activity   []BrokeragePeriod `json:"activity"`
+	TopTickers []BrokerageTicker `json:"top_tickers"`
+}
+
+type BrokeragesResponse struct {
+	Since      *time.Time       `json:"since"`
+	Brokerages []BrokerageStats `json:"brokerages"`
+}
+
+// brokerageActivity es la tabla derivada sobre la que se calculan las estadísticas:
+// una fila por calificación con el nombre canónico del brokerage (o el recibido si no está registrado)
+func brokerageActivity(filter *queryBuilder) string {
+	return "(SELECT COALESCE((SELECT name FROM brokerages WHERE id = stocks.brokerage_id), brokerage) AS name," +
+		" brokerage AS raw_name, ticker, company, record_time, " +
+		model.DirectionExpr + " AS direction, " +
+		model.TargetChangePctExpr + " AS target_change_pct" +
+		" FROM stocks" + filter.whereClause() + ") AS activity"
+}
+
+const brokerageStatsColumns = "COUNT(*)," +
+	" COALESCE(SUM(CASE WHEN direction = 'upgrade' THEN 1 ELSE 0 END), 0)::INT," +
+	" COALESCE(SUM(CASE WHEN direction = 'downgrade' THEN 1 ELSE 0 END), 0)::INT," +
+	" AVG(target_change_pct)," +
+	" COUNT(DISTINCT ticker)," +
+	" MAX(record_time)"
+
+func (s *BrokerageStats) fields() []any {
+	return []any{&s.Actions, &s.Upgrades, &s.Downgrades, &s.AvgTargetChangePct, &s.Tickers, &s.LastRecordTime}
+}
+
+func (s *BrokerageStats) computeRatio() {
+	if s.Downgrades > 0 {
+		ratio := float64(s.Upgrades) / float64(s.Downgrades)
+		s.UpgradeDowngradeRatio = &ratio
+	}
+}
+
+// brokerageOrder son los campos permitidos en order_by del ranking de brokerages
+var brokerageOrder = map[string]func(BrokerageStats) *float64{
+	"actions":                 func(s BrokerageStats) *float64 { return floatPtr(float64(s.Actions)) },
+	"tickers":                 func(s BrokerageStats) *float64 { return floatPtr(float64(s.Tickers)) },
+	"upgrade_downgrade_ratio": func(s BrokerageStats) *float64 { return s.UpgradeDowngradeRatio },
+	"avg_target_change_pct":   func(s BrokerageStats) *float64 { return s.AvgTargetChangePct },
+}
+
+// getSince devuelve el inicio de la ventana si se pidió days=; sin days se usa todo el historial
+func getSince(params url.Values, filter *queryBuilder) (*time.Time, error) {
+	if params.Get("days") == "" {
+		return nil, nil
+	}
+	since, err := parseDays(params.Get("days"))
+	if err != nil {
+		return nil, err
+	}
+	filter.where("record_time >= " + filter.arg(since))
+	return &since, nil
+}
+
+// GetBrokerages devuelve el ranking de brokerages por actividad.
+//
+// Parámetros: days (ventana; por defecto todo el historial), per_page, order_by (actions por defecto,
+// tickers, upgrade_downgrade_ratio o avg_target_change_pct) y asc=1.
+func GetBrokerages(params url.Values) (BrokeragesResponse, error) {
+	filter := &queryBuilder{}
+	since, err := getSince(params, filter)
+	if err != nil {
+		return BrokeragesResponse{}, err
+	}
+	limit, err := getPerPage(params.Get("per_page"))
+	if err != nil {
+		return BrokeragesResponse{}, err
+	}
+	orderBy := params.Get("order_by")
+	if orderBy == "" {
+		orderBy = "actions"
+	}
+	sortValue, ok := brokerageOrder[orderBy]
+	if !ok {
+		return BrokeragesResponse{}, &ParamError{Param: "order_by", Message: "debe ser actions, tickers, upgrade_downgrade_ratio o avg_target_change_pct"}
+	}
+	asc := params.Get("asc") == "1"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := "SELECT name, " + brokerageStatsColumns + " FROM " + brokerageActivity(filter) +
+		" WHERE name IS NOT NULL GROUP BY name"
+	rows, err := pool.Query(ctx, query, filter.args...)
+	if err != nil {
+		log.Printf("query error: %v", err)
+		return BrokeragesResponse{}, err
+	}
+	defer rows.Close()
+
+	brokerages := []BrokerageStats{}
+	for rows.Next() {
+		var stats BrokerageStats
+		if err := rows.Scan(append([]any{&stats.Name}, stats.fields()...)...); err != nil {
+			log.Printf("scan error: %v", err)
+			return BrokeragesResponse{}, err
+		}
+		stats.computeRatio()
+		brokerages = append(brokerages, stats)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("rows error: %v", err)
+		return BrokeragesResponse{}, err
+	}
+
+	// Los brokerages sin valor para el orden quedan al final
+	sortNilLast(brokerages, sortValue, func(s BrokerageStats) string { return s.Name }, asc)
+
+	if len(brokerages) > limit {
+		brokerages = brokerages[:limit]
+	}
+	return BrokeragesResponse{Since: since, Brokerages: brokerages}, nil
+}
+
+// periods son las unidades de agrupación permitidas en period=
+var periods = []string{"day", "week", "month"}
+
+// GetBrokerage devuelve las estadísticas de un brokerage (por nombre canónico o el recibido de la API),
+// su actividad por período y los tickers que más cubre.
+//
+// Parámetros: days (ventana; por defecto todo el historial), period (day, week o month; por defecto week)
+// y top (cantidad de tickers, por defecto 10).
+func GetBrokerage(name string, params url.Values) (BrokerageDetail, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return BrokerageDetail{}, &ParamError{Param: "name", Message: "no puede estar vacío"}
+	}
+	filter := &queryBuilder{}
+	if _, err := getSince(params, filter); err != nil {
+		return BrokerageDetail{}, err
+	}
+	period := params.Get("period")
+	if period == "" {
+		period = "week"
+	}
+	if !slices.Contains(periods, period) {
+		return BrokerageDetail{}, &ParamError{Param: "period", Message: "debe ser day, week o month"}
+	}
+	top := 10
+	if value := params.Get("top"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return BrokerageDetail{}, &ParamError{Param: "top", Message: "debe ser un entero mayor que 0"}
+		}
+		top = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	from := " FROM " + brokerageActivity(filter) + " WHERE (name = " + filter.arg(name) + " OR raw_name = " + filter.arg(name) + ")"
+
+	detail := BrokerageDetail{Period: period, Activity: []BrokeragePeriod{}, TopTickers: []BrokerageTicker{}}
+	var canonical *string
+	err := pool.QueryRow(ctx, "SELECT MIN(name), "+brokerageStatsColumns+from, filter.args...).
+		Scan(append([]any{&canonical}, detail.fields()...)...)
+	if err != nil {
+		log.Printf("query error: %v", err)
+		return BrokerageDetail{}, err
+	}
+	if detail.Actions == 0 || canonical == nil {
+		return BrokerageDetail{}, fmt.Errorf("brokerage %s: %w", name, ErrNotFound)
+	}
+	detail.Name = *canonical
+	detail.computeRatio()
+
+	// period viene de la lista permitida, por eso puede ir en el texto del SQL
+	rows, err := pool.Query(ctx, "SELECT date_trunc('"+period+"', record_time) AS start, COUNT(*),"+
+		" COALESCE(SUM(CASE WHEN direction = 'upgrade' THEN 1 ELSE 0 END), 0)::INT,"+
+		" COALESCE(SUM(CASE WHEN direction = 'downgrade' THEN 1 ELSE 0 END), 0)::INT"+
+		from+" AND record_time IS NOT NULL GROUP BY start ORDER BY start ASC", filter.args...)
+	if err != nil {
+		log.Printf("query error: %v", err)
+		return BrokerageDetail{}, err
+	}
+	for rows.Next() {
+		var activity BrokeragePeriod
+		if err := rows.Scan(&activity.Start, &activity.Actions, &activity.Upgrades, &activity.Downgrades); err != nil {
+			rows.Close()
+			log.Printf("scan error: %v", err)
+			return BrokerageDetail{}, err
+		}
+		detail.Activity = append(detail.Activity, activity)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		log.Printf("rows error: %v", err)
+		return BrokerageDetail{}, err
+	}
+
+	rows, err = pool.Query(ctx, "SELECT ticker, MAX(company), COUNT(*) AS actions, MAX(record_time)"+
+		from+" AND ticker IS NOT NULL GROUP BY ticker ORDER BY actions DESC, ticker ASC LIMIT "+filter.arg(top), filter.args...)
+	if err != nil {
+		log.Printf("query error: %v", err)
+		return BrokerageDetail{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ticker BrokerageTicker
+		if err := rows.Scan(&ticker.Ticker, &ticker.Company, &ticker.Actions, &ticker.LastRecordTime); err != nil {
+			log.Printf("scan error: %v", err)
+			return BrokerageDetail{}, err
+		}
+		detail.TopTickers = append(detail.TopTickers, ticker)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("rows error: %v", err)
+		return BrokerageDetail{}, err
+	}
+
+	return detail, nil
+}
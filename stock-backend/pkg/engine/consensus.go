@@ -0,0 +1,270 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Consensus resume la última calificación de cada brokerage sobre un ticker dentro de la ventana.
+//
+// Los ratings se cuentan en la escala normalizada: buy con score 4 o más, hold con 3 y sell con 2 o menos;
+// unrated son los que no están en el vocabulario. El feed no trae el precio de mercado, por lo que
+// upside_pct mide cuánto subieron los targets: (media de target_to - media de target_from) / media de target_from * 100.
+type Consensus struct {
+	Ticker       string   `json:"ticker"`
+	Company      *string  `json:"company"`
+	Ratings      int      `json:"ratings"`
+	MeanTarget   *float64 `json:"mean_target"`
+	MedianTarget *float64 `json:"median_target"`
+	HighTarget   *float64 `json:"high_target"`
+	LowTarget    *float64 `json:"low_target"`
+	Buy          int      `json:"buy"`
+	Hold         int      `json:"hold"`
+	Sell         int      `json:"sell"`
+	Unrated      int      `json:"unrated"`
+	// TargetStdDev es la desviación estándar de los targets y Dispersion la misma relativa a la media
+	TargetStdDev *float64 `json:"target_stddev"`
+	Dispersion   *float64 `json:"dispersion"`
+	UpsidePct    *float64 `json:"upside_pct"`
+	// LatestRatings son las calificaciones usadas; solo se incluyen en la consulta de un ticker
+	LatestRatings []Stock `json:"latest_ratings,omitempty"`
+}
+
+type ConsensusResponse struct {
+	Since     time.Time   `json:"since"`
+	Consensus []Consensus `json:"consensus"`
+}
+
+// consensusOrder son los campos permitidos en order_by de la lista de consensos
+var consensusOrder = map[string]func(Consensus) *float64{
+	"upside_pct":  func(c Consensus) *float64 { return c.UpsidePct },
+	"mean_target": func(c Consensus) *float64 { return c.MeanTarget },
+	"dispersion":  func(c Consensus) *float64 { return c.Dispersion },
+	"ratings":     func(c Consensus) *float64 { return floatPtr(float64(c.Ratings)) },
+}
+
+// defaultLookbackDays es la ventana por defecto del consenso; CONSENSUS_LOOKBACK_DAYS se lee una sola vez
+var defaultLookbackDays = sync.OnceValue(func() int {
+	days := 90
+	if env := os.Getenv("CONSENSUS_LOOKBACK_DAYS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			days = parsed
+		} else {
+			log.Printf("CONSENSUS_LOOKBACK_DAYS inválido (%q), se usa %d", env, days)
+		}
+	}
+	return days
+})
+
+// getLookback interpreta days= (por defecto CONSENSUS_LOOKBACK_DAYS o 90) y devuelve el inicio de la ventana
+func getLookback(params url.Values) (time.Time, error) {
+	if value := params.Get("days"); value != "" {
+		return parseDays(value)
+	}
+	return time.Now().UTC().AddDate(0, 0, -defaultLookbackDays()), nil
+}
+
+// parseDays valida days= y devuelve el inicio de la ventana de esa cantidad de días
+func parseDays(value string) (time.Time, error) {
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 {
+		return time.Time{}, &ParamError{Param: "days", Message: "debe ser un entero mayor que 0"}
+	}
+	return time.Now().UTC().AddDate(0, 0, -days), nil
+}
+
+// latestRatings trae la última calificación de cada brokerage por ticker desde since.
+// Los brokerages se agrupan por el registro canónico cuando la fila lo tiene.
+func latestRatings(ctx context.Context, since time.Time, ticker string) (map[string][]Stock, error) {
+	filter := &queryBuilder{}
+	filter.where("record_time >= " + filter.arg(since))
+	filter.where("ticker IS NOT NULL")
+	if ticker != "" {
+		filter.where("ticker = " + filter.arg(ticker))
+	}
+
+	brokerageKey := "COALESCE(brokerage_id::TEXT, brokerage)"
+	query := "SELECT DISTINCT ON (ticker, " + brokerageKey + ") " + stockColumns + " FROM stocks" +
+		filter.whereClause() + " ORDER BY ticker, " + brokerageKey + ", record_time DESC, code DESC"
+
+	rows, err := pool.Query(ctx, query, filter.args...)
+	if err != nil {
+		log.Printf("query error: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	byTicker := map[string][]Stock{}
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			log.Printf("scan error: %v", err)
+			continue
+		}
+		byTicker[*stock.Ticker] = append(byTicker[*stock.Ticker], stock)
+	}
+	return byTicker, rows.Err()
+}
+
+// newConsensus calcula el consenso de un ticker a partir de sus últimas calificaciones
+func newConsensus(ticker string, ratings []Stock) Consensus {
+	consensus := Consensus{Ticker: ticker, Ratings: len(ratings)}
+
+	var targets []float64
+	var sumFrom, sumTo float64
+	var moves int
+	for _, stock := range ratings {
+		if stock.Company != nil {
+			consensus.Company = stock.Company
+		}
+
+		switch {
+		case stock.RatingToScore == nil:
+			consensus.Unrated++
+		case *stock.RatingToScore >= 4:
+			consensus.Buy++
+		case *stock.RatingToScore == 3:
+			consensus.Hold++
+		default:
+			consensus.Sell++
+		}
+
+		if stock.TargetTo != nil {
+			targets = append(targets, *stock.TargetTo)
+		}
+		if stock.TargetFrom != nil && stock.TargetTo != nil && *stock.TargetFrom > 0 {
+			sumFrom += *stock.TargetFrom
+			sumTo += *stock.TargetTo
+			moves++
+		}
+	}
+
+	if len(targets) > 0 {
+		slices.Sort(targets)
+		var sum float64
+		for _, target := range targets {
+			sum += target
+		}
+		mean := sum / float64(len(targets))
+
+		var squares float64
+		for _, target := range targets {
+			squares += (target - mean) * (target - mean)
+		}
+		stddev := math.Sqrt(squares / float64(len(targets)))
+
+		median := targets[len(targets)/2]
+		if len(targets)%2 == 0 {
+			median = (targets[len(targets)/2-1] + median) / 2
+		}
+
+		consensus.MeanTarget = &mean
+		consensus.MedianTarget = &median
+		consensus.HighTarget = &targets[len(targets)-1]
+		consensus.LowTarget = &targets[0]
+		consensus.TargetStdDev = &stddev
+		if mean > 0 {
+			dispersion := stddev / mean
+			consensus.Dispersion = &dispersion
+		}
+	}
+
+	if moves > 0 {
+		upside := (sumTo - sumFrom) / sumFrom * 100
+		consensus.UpsidePct = &upside
+	}
+
+	return consensus
+}
+
+// GetTickerConsensus calcula el consenso de un ticker con la última calificación de cada brokerage
+// en los últimos days días
+func GetTickerConsensus(ticker string, params url.Values) (ConsensusResponse, error) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if ticker == "" {
+		return ConsensusResponse{}, &ParamError{Param: "ticker", Message: "no puede estar vacío"}
+	}
+	since, err := getLookback(params)
+	if err != nil {
+		return ConsensusResponse{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	byTicker, err := latestRatings(ctx, since, ticker)
+	if err != nil {
+		return ConsensusResponse{}, err
+	}
+	ratings := byTicker[ticker]
+	if len(ratings) == 0 {
+		return ConsensusResponse{}, fmt.Errorf("ticker %s sin calificaciones desde %s: %w", ticker, since.Format(time.DateOnly), ErrNotFound)
+	}
+
+	consensus := newConsensus(ticker, ratings)
+	consensus.LatestRatings = ratings
+	return ConsensusResponse{Since: since, Consensus: []Consensus{consensus}}, nil
+}
+
+// GetConsensusList calcula el consenso de todos los tickers con calificaciones en la ventana.
+//
+// Parámetros: days, min_ratings (brokerages mínimos por ticker, por defecto 1), per_page,
+// order_by (upside_pct por defecto, mean_target, dispersion o ratings) y asc=1. Los tickers
+// sin valor para el orden quedan al final.
+func GetConsensusList(params url.Values) (ConsensusResponse, error) {
+	since, err := getLookback(params)
+	if err != nil {
+		return ConsensusResponse{}, err
+	}
+	limit, err := getPerPage(params.Get("per_page"))
+	if err != nil {
+		return ConsensusResponse{}, err
+	}
+	minRatings := 1
+	if value := params.Get("min_ratings"); value != "" {
+		minRatings, err = strconv.Atoi(value)
+		if err != nil || minRatings < 1 {
+			return ConsensusResponse{}, &ParamError{Param: "min_ratings", Message: "debe ser un entero mayor que 0"}
+		}
+	}
+	orderBy := params.Get("order_by")
+	if orderBy == "" {
+		orderBy = "upside_pct"
+	}
+	sortValue, ok := consensusOrder[orderBy]
+	if !ok {
+		return ConsensusResponse{}, &ParamError{Param: "order_by", Message: "debe ser upside_pct, mean_target, dispersion o ratings"}
+	}
+	asc := params.Get("asc") == "1"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	byTicker, err := latestRatings(ctx, since, "")
+	if err != nil {
+		return ConsensusResponse{}, err
+	}
+
+	list := []Consensus{}
+	for ticker, ratings := range byTicker {
+		if len(ratings) >= minRatings {
+			list = append(list, newConsensus(ticker, ratings))
+		}
+	}
+
+	sortNilLast(list, sortValue, func(c Consensus) string { return c.Ticker }, asc)
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return ConsensusResponse{Since: since, Consensus: list}, nil
+}
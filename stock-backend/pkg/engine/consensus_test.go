@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// rating arma una calificación con score y targets; nil deja el campo vacío
+func rating(score *int, from, to *float64) Stock {
+	return Stock{RatingToScore: score, TargetFrom: from, TargetTo: to}
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestNewConsensus(t *testing.T) {
+	tests := []struct {
+		name                     string
+		ratings                  []Stock
+		buy, hold, sell, unrated int
+		mean, median, stddev     *float64
+		high, low                *float64
+		dispersion, upside       *float64
+	}{
+		{
+			name: "impar",
+			ratings: []Stock{
+				rating(intPtr(5), floatPtr(100), floatPtr(120)),
+				rating(intPtr(4), floatPtr(100), floatPtr(90)),
+				rating(intPtr(3), floatPtr(100), floatPtr(150)),
+			},
+			buy: 2, hold: 1,
+			mean: floatPtr(120), median: floatPtr(120), high: floatPtr(150), low: floatPtr(90),
+			stddev:     floatPtr(math.Sqrt(600)),
+			dispersion: floatPtr(math.Sqrt(600) / 120),
+			upside:     floatPtr(20),
+		},
+		{
+			name: "par: la mediana promedia los del medio",
+			ratings: []Stock{
+				rating(intPtr(2), floatPtr(50), floatPtr(40)),
+				rating(intPtr(1), floatPtr(50), floatPtr(10)),
+				rating(nil, floatPtr(50), floatPtr(30)),
+				rating(intPtr(3), floatPtr(50), floatPtr(20)),
+			},
+			hold: 1, sell: 2, unrated: 1,
+			mean: floatPtr(25), median: floatPtr(25), high: floatPtr(40), low: floatPtr(10),
+			stddev:     floatPtr(math.Sqrt(125)),
+			dispersion: floatPtr(math.Sqrt(125) / 25),
+			upside:     floatPtr(-50),
+		},
+		{
+			name: "media 0 sin dispersión y upside sin target_from 0",
+			ratings: []Stock{
+				rating(intPtr(4), floatPtr(0), floatPtr(0)),
+				rating(intPtr(4), nil, floatPtr(0)),
+			},
+			buy:  2,
+			mean: floatPtr(0), median: floatPtr(0), high: floatPtr(0), low: floatPtr(0),
+			stddev: floatPtr(0),
+		},
+		{
+			name: "upside ignora target_from 0",
+			ratings: []Stock{
+				rating(intPtr(4), floatPtr(0), floatPtr(80)),
+				rating(intPtr(4), floatPtr(50), floatPtr(60)),
+			},
+			buy:  2,
+			mean: floatPtr(70), median: floatPtr(70), high: floatPtr(80), low: floatPtr(60),
+			stddev:     floatPtr(10),
+			dispersion: floatPtr(10.0 / 70),
+			upside:     floatPtr(20),
+		},
+		{
+			name:    "sin targets",
+			ratings: []Stock{rating(nil, nil, nil)},
+			unrated: 1,
+		},
+	}
+
+	for _, test := range tests {
+		got := newConsensus("AAPL", test.ratings)
+		if got.Ticker != "AAPL" || got.Ratings != len(test.ratings) {
+			t.Errorf("%s: ticker = %s, ratings = %d", test.name, got.Ticker, got.Ratings)
+		}
+		if got.Buy != test.buy || got.Hold != test.hold || got.Sell != test.sell || got.Unrated != test.unrated {
+			t.Errorf("%s: buy/hold/sell/unrated = %d/%d/%d/%d, want %d/%d/%d/%d", test.name,
+				got.Buy, got.Hold, got.Sell, got.Unrated, test.buy, test.hold, test.sell, test.unrated)
+		}
+		for field, values := range map[string][2]*float64{
+			"mean_target":   {got.MeanTarget, test.mean},
+			"median_target": {got.MedianTarget, test.median},
+			"high_target":   {got.HighTarget, test.high},
+			"low_target":    {got.LowTarget, test.low},
+			"target_stddev": {got.TargetStdDev, test.stddev},
+			"dispersion":    {got.Dispersion, test.dispersion},
+			"upside_pct":    {got.UpsidePct, test.upside},
+		} {
+			if !closeTo(values[0], values[1]) {
+				t.Errorf("%s: %s = %s, want %s", test.name, field, format(values[0]), format(values[1]))
+			}
+		}
+	}
+}
+
+func closeTo(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return math.Abs(*a-*b) < 1e-9
+}
+
+func format(value *float64) string {
+	if value == nil {
+		return "nil"
+	}
+	return fmt.Sprint(*value)
+}
@@ -1,32 +1,13 @@
 package engine
 
 import (
-	"context"
-	"fmt"
-	"log"
-	"os"
-
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func connectToDB() (*pgx.Conn, error) {
-
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	database := os.Getenv("DB_DATABASE")
-
-	url := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=require", user, password, host, port, database)
-	dsn := fmt.Sprintf(url)
-
-	db, err := pgx.Connect(context.Background(), dsn)
-	if err != nil {
-		log.Fatalf("error connecting to database: %v", err)
-	}
-
-	fmt.Println("Conectado a CockroachDB")
-
-	return db, nil
+// pool es el pool de conexiones compartido por todas las consultas del engine
+var pool *pgxpool.Pool
 
+// UsePool inyecta el pool creado al iniciar el servidor; quien lo crea es responsable de cerrarlo
+func UsePool(p *pgxpool.Pool) {
+	pool = p
 }
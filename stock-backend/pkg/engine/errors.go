@@ -0,0 +1,19 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ParamError indica que un parámetro de la consulta no es válido; los handlers lo responden con 400
+type ParamError struct {
+	Param   string
+	Message string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("parámetro %s inválido: %s", e.Param, e.Message)
+}
+
+// ErrNotFound indica que no existe el registro pedido; los handlers lo responden con 404
+var ErrNotFound = errors.New("no encontrado")
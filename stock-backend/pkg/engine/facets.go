@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+)
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets son los valores distintos de cada filtro de texto con la cantidad de stocks
+type Facets struct {
+	Brokerage  []FacetValue `json:"brokerage"`
+	Action     []FacetValue `json:"action"`
+	RatingFrom []FacetValue `json:"rating_from"`
+	RatingTo   []FacetValue `json:"rating_to"`
+}
+
+// GetFacets cuenta los stocks por brokerage (nombre canónico si está registrado), action y ratings.
+//
+// Cada faceta aplica todos los filtros recibidos salvo el suyo (incluidas sus exclusiones),
+// para que el panel siga mostrando las alternativas al valor ya elegido.
+func GetFacets(params url.Values) (Facets, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var facets Facets
+	targets := []struct {
+		param      string
+		expression string
+		values     *[]FacetValue
+	}{
+		{"brokerage", "COALESCE((SELECT name FROM brokerages WHERE id = stocks.brokerage_id), brokerage)", &facets.Brokerage},
+		{"action", "action", &facets.Action},
+		{"rating_from", "rating_from", &facets.RatingFrom},
+		{"rating_to", "rating_to", &facets.RatingTo},
+	}
+
+	for _, target := range targets {
+		others := url.Values{}
+		for key, values := range params {
+			if key != target.param && key != target.param+"_in" && key != "-"+target.param {
+				others[key] = values
+			}
+		}
+
+		filter, err := getWhereClause(others)
+		if err != nil {
+			return Facets{}, err
+		}
+		filter.where(target.expression + " IS NOT NULL")
+
+		query := "SELECT value, COUNT(*) AS count FROM (SELECT " + target.expression + " AS value FROM stocks" +
+			filter.whereClause() + ") AS facet GROUP BY value ORDER BY count DESC, value ASC"
+
+		rows, err := pool.Query(ctx, query, filter.args...)
+		if err != nil {
+			log.Printf("facet query error: %v", err)
+			return Facets{}, err
+		}
+
+		*target.values = []FacetValue{}
+		for rows.Next() {
+			var value FacetValue
+			if err := rows.Scan(&value.Value, &value.Count); err != nil {
+				rows.Close()
+				log.Printf("scan error: %v", err)
+				return Facets{}, err
+			}
+			*target.values = append(*target.values, value)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			log.Printf("rows error: %v", err)
+			return Facets{}, err
+		}
+	}
+
+	return facets, nil
+}
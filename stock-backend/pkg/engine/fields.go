@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// stockFields son las claves JSON de Stock que se pueden pedir con fields=
+var stockFields = jsonKeys(Stock{})
+
+func jsonKeys(value any) []string {
+	payload, _ := json.Marshal(value)
+	var object map[string]json.RawMessage
+	json.Unmarshal(payload, &object)
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// parseFields valida la lista separada por comas de fields=; vacía significa todos los campos
+func parseFields(value string) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(stockFields, field) {
+			return nil, &ParamError{Param: "fields", Message: "campo desconocido " + field + " (permitidos: " + strings.Join(stockFields, ", ") + ")"}
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// projectStocks deja en cada stock solo las claves pedidas
+func projectStocks(stocks []Stock, fields []string) ([]map[string]json.RawMessage, error) {
+	if stocks == nil {
+		return nil, nil
+	}
+	projected := make([]map[string]json.RawMessage, len(stocks))
+	for i, stock := range stocks {
+		payload, err := json.Marshal(stock)
+		if err != nil {
+			return nil, err
+		}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &object); err != nil {
+			return nil, err
+		}
+		projected[i] = make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			projected[i][field] = object[field]
+		}
+	}
+	return projected, nil
+}
+
+// MarshalJSON mantiene la forma de la respuesta y, si se pidió fields=, recorta cada stock
+func (r PaginatedStocksResponse) MarshalJSON() ([]byte, error) {
+	type plain PaginatedStocksResponse
+	if len(r.Fields) == 0 {
+		return json.Marshal(plain(r))
+	}
+	stocks, err := projectStocks(r.Stocks, r.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		plain
+		Stocks []map[string]json.RawMessage `json:"stocks"`
+	}{plain(r), stocks})
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"ticker", []string{"ticker"}},
+		{" ticker , target_to,record_time ", []string{"ticker", "target_to", "record_time"}},
+		{"ticker,,ticker,direction,ticker", []string{"ticker", "direction"}},
+		{",", nil},
+	}
+	for _, test := range tests {
+		got, err := parseFields(test.value)
+		if err != nil {
+			t.Errorf("parseFields(%q): %v", test.value, err)
+			continue
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("parseFields(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestParseFieldsRejectsUnknown(t *testing.T) {
+	for _, value := range []string{"precio", "ticker,run_id", "Ticker", "ticker,stocks"} {
+		var paramErr *ParamError
+		if _, err := parseFields(value); !errors.As(err, &paramErr) || paramErr.Param != "fields" {
+			t.Errorf("parseFields(%q): err = %v, want ParamError for fields", value, err)
+		}
+	}
+}
+
+func TestStockFieldsIncludeComputed(t *testing.T) {
+	for _, field := range []string{"code", "ticker", "brokerage_name", "target_change_pct", "rating_delta", "direction"} {
+		if !slices.Contains(stockFields, field) {
+			t.Errorf("stockFields no incluye %s", field)
+		}
+	}
+	if slices.Contains(stockFields, "run_id") {
+		t.Error("stockFields incluye run_id, que no se serializa")
+	}
+}
+
+func TestPaginatedStocksResponseProjectsFields(t *testing.T) {
+	ticker, targetTo := "AAPL", 220.0
+	recordTime := time.Date(2025, 7, 17, 0, 30, 7, 155596000, time.UTC)
+	stock := Stock{Code: uuid.New(), Ticker: &ticker, TargetTo: &targetTo, RecordTime: &recordTime, Direction: "upgrade"}
+	response := PaginatedStocksResponse{Stocks: []Stock{stock, {Code: uuid.New()}}, CurrentPage: 1, PerPage: 2}
+
+	payload, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full struct {
+		Stocks []map[string]any `json:"stocks"`
+	}
+	if err := json.Unmarshal(payload, &full); err != nil {
+		t.Fatal(err)
+	}
+	if len(full.Stocks[0]) != len(stockFields) {
+		t.Errorf("sin fields: %d campos, want %d", len(full.Stocks[0]), len(stockFields))
+	}
+
+	response.Fields = []string{"ticker", "direction", "target_change_pct"}
+	payload, err = json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var projected struct {
+		Stocks      []map[string]any `json:"stocks"`
+		CurrentPage int              `json:"current_page"`
+		PerPage     int              `json:"per_page"`
+		Fields      any              `json:"fields"`
+	}
+	if err := json.Unmarshal(payload, &projected); err != nil {
+		t.Fatal(err)
+	}
+	if projected.CurrentPage != 1 || projected.PerPage != 2 || projected.Fields != nil {
+		t.Errorf("el resto de la respuesta cambió: %s", payload)
+	}
+	want := []map[string]any{
+		{"ticker": "AAPL", "direction": "upgrade", "target_change_pct": nil},
+		{"ticker": nil, "direction": "", "target_change_pct": nil},
+	}
+	if len(projected.Stocks) != len(want) {
+		t.Fatalf("stocks = %v, want %v", projected.Stocks, want)
+	}
+	for i := range want {
+		if len(projected.Stocks[i]) != len(want[i]) {
+			t.Errorf("stock %d = %v, want %v", i, projected.Stocks[i], want[i])
+		}
+		for key, value := range want[i] {
+			if got, ok := projected.Stocks[i][key]; !ok || got != value {
+				t.Errorf("stock %d: %s = %v, want %v", i, key, got, value)
+			}
+		}
+	}
+}
+
+func TestPaginatedStocksResponseKeepsEmptyStocks(t *testing.T) {
+	for _, stocks := range [][]Stock{nil, {}} {
+		payload, err := json.Marshal(PaginatedStocksResponse{Stocks: stocks, Fields: []string{"ticker"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var response map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &response); err != nil {
+			t.Fatal(err)
+		}
+		want := "null"
+		if stocks != nil {
+			want = "[]"
+		}
+		if got := string(response["stocks"]); got != want {
+			t.Errorf("stocks %v: %s, want %s", stocks, got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HistoryEvent es una calificación dentro del historial de un ticker junto con
+// el movimiento del target respecto del evento anterior
+type HistoryEvent struct {
+	Stock
+	// PreviousTargetTo es el target_to del evento anterior con target, de cualquier brokerage
+	PreviousTargetTo *float64 `json:"previous_target_to"`
+	// TargetMove es target_to - previous_target_to
+	TargetMove *float64 `json:"target_move"`
+}
+
+// HistoryBrokerage resume la participación de un brokerage en el historial
+type HistoryBrokerage struct {
+	Name   string     `json:"name"`
+	Events int        `json:"events"`
+	First  *time.Time `json:"first_record_time"`
+	Last   *time.Time `json:"last_record_time"`
+}
+
+type TickerHistory struct {
+	Ticker     string             `json:"ticker"`
+	Company    *string            `json:"company"`
+	Events     []HistoryEvent     `json:"events"`
+	Brokerages []HistoryBrokerage `json:"brokerages"`
+}
+
+// GetStock devuelve una calificación por su code
+func GetStock(code string) (Stock, error) {
+	id, err := uuid.Parse(code)
+	if err != nil {
+		return Stock{}, &ParamError{Param: "code", Message: "debe ser un UUID"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := pool.Query(ctx, "SELECT "+stockColumns+" FROM stocks WHERE code = $1", id)
+	if err != nil {
+		log.Printf("query error: %v", err)
+		return Stock{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return Stock{}, err
+		}
+		return Stock{}, fmt.Errorf("stock %s: %w", code, ErrNotFound)
+	}
+	return scanStock(rows)
+}
+
+// GetTickerHistory devuelve todas las calificaciones de un ticker ordenadas por record_time,
+// con el movimiento del target entre eventos y los brokerages que participaron
+func GetTickerHistory(ticker string) (TickerHistory, error) {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if ticker == "" {
+		return TickerHistory{}, &ParamError{Param: "ticker", Message: "no puede estar vacío"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := "SELECT " + stockColumns + " FROM stocks WHERE ticker = $1" + orderByClause("record_time", true)
+	rows, err := pool.Query(ctx, query, ticker)
+	if err != nil {
+		log.Printf("query error: %v", err)
+		return TickerHistory{}, err
+	}
+	defer rows.Close()
+
+	history := TickerHistory{Ticker: ticker, Events: []HistoryEvent{}, Brokerages: []HistoryBrokerage{}}
+	// Los brokerages quedan en el orden de su primera aparición
+	brokerages := map[string]int{}
+	var previous *float64
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			log.Printf("scan error: %v", err)
+			continue
+		}
+
+		event := HistoryEvent{Stock: stock, PreviousTargetTo: previous}
+		if stock.TargetTo != nil {
+			if previous != nil {
+				move := *stock.TargetTo - *previous
+				event.TargetMove = &move
+			}
+			previous = stock.TargetTo
+		}
+		history.Events = append(history.Events, event)
+
+		if stock.Company != nil {
+			history.Company = stock.Company
+		}
+
+		name := brokerageName(stock)
+		if name == "" {
+			continue
+		}
+		index, ok := brokerages[name]
+		if !ok {
+			index = len(history.Brokerages)
+			brokerages[name] = index
+			history.Brokerages = append(history.Brokerages, HistoryBrokerage{Name: name, First: stock.RecordTime})
+		}
+		history.Brokerages[index].Events++
+		history.Brokerages[index].Last = stock.RecordTime
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("rows error: %v", err)
+		return TickerHistory{}, err
+	}
+	if len(history.Events) == 0 {
+		return TickerHistory{}, fmt.Errorf("ticker %s: %w", ticker, ErrNotFound)
+	}
+
+	return history, nil
+}
+
+// brokerageName prefiere el nombre canónico del registro y si no el recibido de la API
+func brokerageName(stock Stock) string {
+	if stock.BrokerageName != nil {
+		return *stock.BrokerageName
+	}
+	if stock.Brokerage != nil {
+		return *stock.Brokerage
+	}
+	return ""
+}
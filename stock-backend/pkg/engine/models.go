@@ -1,32 +1,23 @@
 package engine
 
-import (
-	"time"
+import "stock/common/model"
 
-	"github.com/google/uuid"
-)
-
-type Stock struct {
-	Code       uuid.UUID  `json:"code"`
-	Ticker     *string    `json:"ticker"`
-	Company    *string    `json:"company"`
-	Brokerage  *string    `json:"brokerage"`
-	Action     *string    `json:"action"`
-	RatingFrom *string    `json:"rating_from"`
-	RatingTo   *string    `json:"rating_to"`
-	TargetFrom *float64   `json:"target_from"`
-	TargetTo   *float64   `json:"target_to"`
-	RecordTime *time.Time `json:"record_time"`
-	CreatedAt  *time.Time `json:"created_at"`
-	UpdatedAt  *time.Time `json:"updated_at"`
-}
+// Stock es el modelo canónico de la tabla stocks compartido con el getter
+type Stock = model.Stock
 
+// PaginatedStocksResponse es una página de la lista de stocks.
+// CurrentPage y NextPage solo aplican a la paginación por offset (0 y null al usar cursor);
+// Total es null cuando no se pidió el conteo.
 type PaginatedStocksResponse struct {
 	Stocks      []Stock `json:"stocks"`
 	CurrentPage int     `json:"current_page"`
 	NextPage    *int    `json:"next_page"`
-	Total       int     `json:"total"`
+	Total       *int    `json:"total"`
 	PerPage     int     `json:"per_page"`
+	NextCursor  *string `json:"next_cursor"`
+	PrevCursor  *string `json:"prev_cursor"`
+	// Fields son las claves de cada stock a devolver (ver fields=); vacío para todas
+	Fields []string `json:"-"`
 }
 
 type Recommendation struct {
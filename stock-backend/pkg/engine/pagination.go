@@ -0,0 +1,154 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"stock/common/model"
+
+	"github.com/google/uuid"
+)
+
+// orderColumn describe una columna por la que se puede ordenar la lista.
+// Los NULL se reemplazan con COALESCE para que el orden y la comparación del cursor
+// sean totales; value reproduce en Go el mismo valor que la expresión SQL.
+type orderColumn struct {
+	expression string
+	value      func(Stock) string
+	parse      func(string) (any, error)
+}
+
+var epoch = time.Unix(0, 0).UTC()
+
+func textColumn(expression string, field func(Stock) *string) orderColumn {
+	return orderColumn{
+		expression: "COALESCE(" + expression + ", '')",
+		value: func(stock Stock) string {
+			if value := field(stock); value != nil {
+				return *value
+			}
+			return ""
+		},
+		parse: func(value string) (any, error) { return value, nil },
+	}
+}
+
+func floatColumn(expression string, field func(Stock) *float64) orderColumn {
+	return orderColumn{
+		expression: "COALESCE(" + expression + ", '-Infinity'::FLOAT8)",
+		value: func(stock Stock) string {
+			if value := field(stock); value != nil {
+				return strconv.FormatFloat(*value, 'g', -1, 64)
+			}
+			return strconv.FormatFloat(math.Inf(-1), 'g', -1, 64)
+		},
+		parse: func(value string) (any, error) { return strconv.ParseFloat(value, 64) },
+	}
+}
+
+func timeColumn(expression string, field func(Stock) *time.Time) orderColumn {
+	return orderColumn{
+		expression: "COALESCE(" + expression + ", '1970-01-01T00:00:00Z'::TIMESTAMPTZ)",
+		value: func(stock Stock) string {
+			if value := field(stock); value != nil {
+				return value.UTC().Format(time.RFC3339Nano)
+			}
+			return epoch.Format(time.RFC3339Nano)
+		},
+		parse: func(value string) (any, error) { return time.Parse(time.RFC3339Nano, value) },
+	}
+}
+
+// orderColumns son los campos permitidos en order_by
+var orderColumns = map[string]orderColumn{
+	"record_time": timeColumn("record_time", func(s Stock) *time.Time { return s.RecordTime }),
+	"created_at":  timeColumn("created_at", func(s Stock) *time.Time { return s.CreatedAt }),
+	"ticker":      textColumn("ticker", func(s Stock) *string { return s.Ticker }),
+	"company":     textColumn("company", func(s Stock) *string { return s.Company }),
+	"brokerage":   textColumn("brokerage", func(s Stock) *string { return s.Brokerage }),
+	"action":      textColumn("action", func(s Stock) *string { return s.Action }),
+	"rating_from": textColumn("rating_from", func(s Stock) *string { return s.RatingFrom }),
+	"rating_to":   textColumn("rating_to", func(s Stock) *string { return s.RatingTo }),
+	"target_from": floatColumn("target_from", func(s Stock) *float64 { return s.TargetFrom }),
+	"target_to":   floatColumn("target_to", func(s Stock) *float64 { return s.TargetTo }),
+
+	"target_change_pct": floatColumn(model.TargetChangePctExpr, func(s Stock) *float64 { return s.TargetChangePct }),
+	"target_change_abs": floatColumn(model.TargetChangeAbsExpr, func(s Stock) *float64 { return s.TargetChangeAbs }),
+	"rating_delta": floatColumn(model.RatingDeltaExpr+"::FLOAT8", func(s Stock) *float64 {
+		if s.RatingDelta == nil {
+			return nil
+		}
+		delta := float64(*s.RatingDelta)
+		return &delta
+	}),
+	"direction": textColumn(model.DirectionExpr, func(s Stock) *string { return &s.Direction }),
+}
+
+// cursor es la posición de una fila dentro de un orden; viaja al cliente como token opaco
+type cursor struct {
+	OrderBy string    `json:"o"`
+	Asc     bool      `json:"a,omitempty"`
+	Value   string    `json:"v"`
+	Code    uuid.UUID `json:"c"`
+	// Prev indica que se piden las filas anteriores a la posición en lugar de las siguientes
+	Prev bool `json:"p,omitempty"`
+}
+
+func newCursor(orderBy string, asc bool, stock Stock, prev bool) *string {
+	token := encodeCursor(cursor{
+		OrderBy: orderBy,
+		Asc:     asc,
+		Value:   orderColumns[orderBy].value(stock),
+		Code:    stock.Code,
+		Prev:    prev,
+	})
+	return &token
+}
+
+func encodeCursor(c cursor) string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, &ParamError{Param: "cursor", Message: "token inválido"}
+	}
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return cursor{}, &ParamError{Param: "cursor", Message: "token inválido"}
+	}
+	if _, ok := orderColumns[c.OrderBy]; !ok {
+		return cursor{}, &ParamError{Param: "cursor", Message: "token inválido"}
+	}
+	return c, nil
+}
+
+// seek agrega la condición que deja solo las filas posteriores (o anteriores) al cursor
+func (q *queryBuilder) seek(c cursor) error {
+	column := orderColumns[c.OrderBy]
+	value, err := column.parse(c.Value)
+	if err != nil {
+		return &ParamError{Param: "cursor", Message: "token inválido"}
+	}
+
+	operator := "<"
+	if c.Asc != c.Prev {
+		operator = ">"
+	}
+	q.where("(" + column.expression + ", code) " + operator + " (" + q.arg(value) + ", " + q.arg(c.Code) + ")")
+	return nil
+}
+
+// orderByClause ordena por la columna indicada y desempata por code para que el orden sea estable
+func orderByClause(orderBy string, asc bool) string {
+	direction := " DESC"
+	if asc {
+		direction = " ASC"
+	}
+	return " ORDER BY " + orderColumns[orderBy].expression + direction + ", code" + direction
+}
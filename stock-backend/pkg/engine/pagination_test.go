@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var cursorCode = uuid.MustParse("6f1c2a4e-0c5b-5d7e-9a3f-1b2c3d4e5f60")
+
+func TestSeek(t *testing.T) {
+	tests := []struct {
+		name     string
+		cursor   cursor
+		want     string
+		wantArgs []any
+	}{
+		{
+			name:     "descendente hacia adelante",
+			cursor:   cursor{OrderBy: "created_at", Value: "2025-07-17T00:30:07.155596Z", Code: cursorCode},
+			want:     " WHERE (COALESCE(created_at, '1970-01-01T00:00:00Z'::TIMESTAMPTZ), code) < ($1, $2)",
+			wantArgs: []any{time.Date(2025, 7, 17, 0, 30, 7, 155596000, time.UTC), cursorCode},
+		},
+		{
+			name:     "descendente hacia atrás",
+			cursor:   cursor{OrderBy: "created_at", Value: "2025-07-17T00:30:07.155596Z", Code: cursorCode, Prev: true},
+			want:     " WHERE (COALESCE(created_at, '1970-01-01T00:00:00Z'::TIMESTAMPTZ), code) > ($1, $2)",
+			wantArgs: []any{time.Date(2025, 7, 17, 0, 30, 7, 155596000, time.UTC), cursorCode},
+		},
+		{
+			name:     "ascendente hacia adelante",
+			cursor:   cursor{OrderBy: "ticker", Asc: true, Value: "AAPL", Code: cursorCode},
+			want:     " WHERE (COALESCE(ticker, ''), code) > ($1, $2)",
+			wantArgs: []any{"AAPL", cursorCode},
+		},
+		{
+			name:     "ascendente hacia atrás",
+			cursor:   cursor{OrderBy: "target_to", Asc: true, Value: "-Inf", Code: cursorCode, Prev: true},
+			want:     " WHERE (COALESCE(target_to, '-Infinity'::FLOAT8), code) < ($1, $2)",
+			wantArgs: []any{math.Inf(-1), cursorCode},
+		},
+	}
+
+	for _, test := range tests {
+		builder := &queryBuilder{}
+		if err := builder.seek(test.cursor); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := builder.whereClause(); got != test.want {
+			t.Errorf("%s: where = %q, want %q", test.name, got, test.want)
+		}
+		if fmt.Sprint(builder.args) != fmt.Sprint(test.wantArgs) {
+			t.Errorf("%s: args = %v, want %v", test.name, builder.args, test.wantArgs)
+		}
+	}
+}
+
+func TestSeekRejectsInvalidValue(t *testing.T) {
+	for _, c := range []cursor{
+		{OrderBy: "record_time", Value: "ayer", Code: cursorCode},
+		{OrderBy: "target_from", Value: "cien", Code: cursorCode},
+	} {
+		var paramErr *ParamError
+		if err := (&queryBuilder{}).seek(c); !errors.As(err, &paramErr) {
+			t.Errorf("seek(%+v): err = %v, want ParamError", c, err)
+		}
+	}
+}
+
+func TestOrderByClause(t *testing.T) {
+	tests := []struct {
+		orderBy string
+		asc     bool
+		want    string
+	}{
+		{"created_at", false, " ORDER BY COALESCE(created_at, '1970-01-01T00:00:00Z'::TIMESTAMPTZ) DESC, code DESC"},
+		{"ticker", true, " ORDER BY COALESCE(ticker, '') ASC, code ASC"},
+		{"target_to", false, " ORDER BY COALESCE(target_to, '-Infinity'::FLOAT8) DESC, code DESC"},
+	}
+	for _, test := range tests {
+		if got := orderByClause(test.orderBy, test.asc); got != test.want {
+			t.Errorf("orderByClause(%s, %v) = %q, want %q", test.orderBy, test.asc, got, test.want)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalidTokens(t *testing.T) {
+	tests := map[string]string{
+		"base64 inválido":   "no es base64!",
+		"json inválido":     base64.RawURLEncoding.EncodeToString([]byte("{")),
+		"order_by inválido": encodeCursor(cursor{OrderBy: "code; DROP TABLE stocks", Value: "x", Code: cursorCode}),
+	}
+	for name, token := range tests {
+		var paramErr *ParamError
+		if _, err := decodeCursor(token); !errors.As(err, &paramErr) || paramErr.Param != "cursor" {
+			t.Errorf("%s: err = %v, want ParamError for cursor", name, err)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	recordTime := time.Date(2025, 7, 17, 0, 30, 7, 155596000, time.FixedZone("", -5*60*60))
+	ticker := "BRK.B"
+	stock := Stock{Code: cursorCode, RecordTime: &recordTime, Ticker: &ticker}
+
+	tests := []struct {
+		orderBy string
+		asc     bool
+		prev    bool
+		want    any
+	}{
+		{"record_time", false, false, recordTime.UTC()},
+		{"target_to", true, true, math.Inf(-1)},
+		{"ticker", true, false, ticker},
+		{"company", false, true, ""},
+	}
+	for _, test := range tests {
+		c, err := decodeCursor(*newCursor(test.orderBy, test.asc, stock, test.prev))
+		if err != nil {
+			t.Fatalf("%s: %v", test.orderBy, err)
+		}
+		if c.OrderBy != test.orderBy || c.Asc != test.asc || c.Prev != test.prev || c.Code != cursorCode {
+			t.Errorf("%s: cursor = %+v", test.orderBy, c)
+		}
+
+		value, err := orderColumns[c.OrderBy].parse(c.Value)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", test.orderBy, err)
+		}
+		if fmt.Sprint(value) != fmt.Sprint(test.want) {
+			t.Errorf("%s: value = %v, want %v", test.orderBy, value, test.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryBuilder arma las condiciones del WHERE con placeholders ($1, $2, ...) y
+// acumula los valores en args, para que nada de lo que envía el usuario termine en el SQL
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// arg agrega un valor y devuelve el placeholder que lo representa
+func (q *queryBuilder) arg(value any) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// where agrega una condición; se unen con AND
+func (q *queryBuilder) where(condition string) {
+	q.conditions = append(q.conditions, condition)
+}
+
+// whereClause devuelve " WHERE ..." o "" si no hay condiciones
+func (q *queryBuilder) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// likePattern arma un patrón "contiene" escapando los comodines que vengan en el texto
+func likePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(value) + "%"
+}
+
+// exactMatch compara por igualdad con uno o varios valores
+func (q *queryBuilder) exactMatch(values []string) string {
+	if len(values) == 1 {
+		return "= " + q.arg(values[0])
+	}
+	return "= ANY(" + q.arg(values) + ")"
+}
+
+// likeMatch busca uno o varios valores como subcadena; basta con que coincida alguno
+func (q *queryBuilder) likeMatch(values []string) string {
+	if len(values) == 1 {
+		return "LIKE " + q.arg(likePattern(values[0]))
+	}
+	patterns := make([]string, len(values))
+	for i, value := range values {
+		patterns[i] = likePattern(value)
+	}
+	return "LIKE ANY(" + q.arg(patterns) + ")"
+}
@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"stock/common/model"
+)
+
+var filterParams = []struct {
+	key       string
+	value     string
+	condition string
+	args      []any
+}{
+	{"ticker", "AAPL", "ticker LIKE $%d", []any{"%AAPL%"}},
+	{"brokerage", "O'Neil", "(brokerage LIKE $%[1]d OR brokerage_id IN (SELECT id FROM brokerages WHERE name LIKE $%[1]d))", []any{"%O'Neil%"}},
+	{"action", "raised by", "action LIKE $%d", []any{"%raised by%"}},
+	{"rating_from", "Buy", "rating_from = $%d", []any{"Buy"}},
+	{"rating_to", "Sell'; DROP TABLE stocks; --", "rating_to = $%d", []any{"Sell'; DROP TABLE stocks; --"}},
+}
+
+// TestGetWhereClauseCombinations prueba todas las combinaciones de filtros
+func TestGetWhereClauseCombinations(t *testing.T) {
+	for mask := 0; mask < 1<<len(filterParams); mask++ {
+		params := url.Values{}
+		var conditions []string
+		var args []any
+		for i, filter := range filterParams {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			params.Set(filter.key, filter.value)
+			conditions = append(conditions, fmt.Sprintf(filter.condition, len(args)+1))
+			args = append(args, filter.args...)
+		}
+
+		want := ""
+		if len(conditions) > 0 {
+			want = " WHERE " + strings.Join(conditions, " AND ")
+		}
+
+		builder, err := getWhereClause(params)
+		if err != nil {
+			t.Fatalf("params %v: %v", params, err)
+		}
+		if got := builder.whereClause(); got != want {
+			t.Errorf("params %v: where = %q, want %q", params, got, want)
+		}
+		if fmt.Sprint(builder.args) != fmt.Sprint(args) {
+			t.Errorf("params %v: args = %v, want %v", params, builder.args, args)
+		}
+		for key := range params {
+			if value := params.Get(key); strings.Contains(builder.whereClause(), value) {
+				t.Errorf("params %v: value %q leaked into the SQL", params, value)
+			}
+		}
+	}
+}
+
+func TestQueryBuilderPlaceholdersContinueAfterWhere(t *testing.T) {
+	builder, _ := getWhereClause(url.Values{"ticker": {"AAPL"}, "rating_to": {"Buy"}})
+	limit := builder.arg(20)
+	offset := builder.arg(40)
+
+	if limit != "$3" || offset != "$4" {
+		t.Errorf("placeholders = %s, %s, want $3, $4", limit, offset)
+	}
+	if len(builder.args) != 4 {
+		t.Errorf("len(args) = %d, want 4", len(builder.args))
+	}
+}
+
+func TestLikePatternEscapesWildcards(t *testing.T) {
+	tests := map[string]string{
+		"AAPL":    "%AAPL%",
+		"50%":     `%50\%%`,
+		"A_B":     `%A\_B%`,
+		`back\sl`: `%back\\sl%`,
+	}
+	for value, want := range tests {
+		if got := likePattern(value); got != want {
+			t.Errorf("likePattern(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestGetWhereClauseRanges(t *testing.T) {
+	params := url.Values{
+		"ticker":           {"AAPL"},
+		"target_to_min":    {"100"},
+		"target_to_max":    {"250.5"},
+		"target_from_min":  {"0"},
+		"target_from_max":  {"1e3"},
+		"record_time_from": {"2025-07-01T00:00:00Z"},
+		"record_time_to":   {"2025-07-03"},
+		"created_after":    {"2025-07-02T12:00:00"},
+
+		"target_change_pct_min": {"5"},
+		"target_change_pct_max": {"50"},
+		"target_change_abs_min": {"-10"},
+		"target_change_abs_max": {"25.5"},
+		"rating_delta_min":      {"1"},
+		"rating_delta_max":      {"2"},
+	}
+	builder, err := getWhereClause(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := " WHERE ticker LIKE $1 AND target_to >= $2 AND target_to <= $3 AND target_from >= $4 AND target_from <= $5" +
+		" AND record_time >= $6 AND record_time <= $7 AND created_at > $8" +
+		" AND ((target_to - target_from) / NULLIF(target_from, 0) * 100) >= $9" +
+		" AND ((target_to - target_from) / NULLIF(target_from, 0) * 100) <= $10" +
+		" AND (target_to - target_from) >= $11 AND (target_to - target_from) <= $12" +
+		" AND (rating_to_score - rating_from_score)::FLOAT8 >= $13 AND (rating_to_score - rating_from_score)::FLOAT8 <= $14"
+	if got := builder.whereClause(); got != want {
+		t.Errorf("where = %q, want %q", got, want)
+	}
+
+	args := []any{
+		"%AAPL%", 100.0, 250.5, 0.0, 1000.0,
+		time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 7, 3, 23, 59, 59, 999999000, time.UTC),
+		time.Date(2025, 7, 2, 12, 0, 0, 0, time.UTC),
+		5.0, 50.0, -10.0, 25.5, 1.0, 2.0,
+	}
+	if fmt.Sprint(builder.args) != fmt.Sprint(args) {
+		t.Errorf("args = %v, want %v", builder.args, args)
+	}
+}
+
+func TestGetWhereClauseInvalidRanges(t *testing.T) {
+	tests := map[string]string{
+		"target_to_min":    "cien",
+		"target_to_max":    "NaN",
+		"target_from_min":  "$10",
+		"target_from_max":  "Inf",
+		"record_time_from": "ayer",
+		"record_time_to":   "2025-13-01",
+		"created_after":    "17/07/2025",
+	}
+	for param, value := range tests {
+		_, err := getWhereClause(url.Values{param: {value}})
+		var paramErr *ParamError
+		if !errors.As(err, &paramErr) || paramErr.Param != param {
+			t.Errorf("%s=%s: err = %v, want ParamError for %s", param, value, err, param)
+		}
+	}
+}
+
+func TestGetWhereClauseMultiValueAndExclusion(t *testing.T) {
+	params := url.Values{
+		"ticker_in":    {"AAPL", "MSFT", ""},
+		"-brokerage":   {"KeyCorp"},
+		"-action":      {"reiterated by", "target set by"},
+		"rating_to":    {"Buy", "Outperform"},
+		"-rating_from": {"Sell"},
+		"direction":    {"upgrade"},
+		"-direction":   {"neutral", "downgrade"},
+	}
+	builder, err := getWhereClause(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := " WHERE ticker = ANY($1)" +
+		" AND ((brokerage = ANY($2) OR brokerage_id IN (SELECT id FROM brokerages WHERE name = ANY($2)))) IS NOT TRUE" +
+		" AND (action = ANY($3)) IS NOT TRUE" +
+		" AND (rating_from = ANY($4)) IS NOT TRUE" +
+		" AND rating_to = ANY($5)" +
+		" AND " + model.DirectionExpr + " = $6" +
+		" AND (" + model.DirectionExpr + " = ANY($7)) IS NOT TRUE"
+	if got := builder.whereClause(); got != want {
+		t.Errorf("where = %q, want %q", got, want)
+	}
+
+	args := []any{
+		[]string{"AAPL", "MSFT"},
+		[]string{"KeyCorp"},
+		[]string{"reiterated by", "target set by"},
+		[]string{"Sell"},
+		[]string{"Buy", "Outperform"},
+		"upgrade",
+		[]string{"neutral", "downgrade"},
+	}
+	if fmt.Sprint(builder.args) != fmt.Sprint(args) {
+		t.Errorf("args = %v, want %v", builder.args, args)
+	}
+}
+
+// TestGetWhereClauseOneOrManyValues comprueba que uno o varios valores usen la misma comparación:
+// subcadena en el parámetro simple y exacta con el sufijo _in
+func TestGetWhereClauseOneOrManyValues(t *testing.T) {
+	brokerage := func(match string) string {
+		return "(brokerage " + match + " OR brokerage_id IN (SELECT id FROM brokerages WHERE name " + match + "))"
+	}
+	tests := []struct {
+		params url.Values
+		want   string
+		args   []any
+	}{
+		{url.Values{"ticker": {"AA"}}, "ticker LIKE $1", []any{"%AA%"}},
+		{url.Values{"ticker": {"AA", "MS"}}, "ticker LIKE ANY($1)", []any{[]string{"%AA%", "%MS%"}}},
+		{url.Values{"ticker_in": {"AAPL"}}, "ticker = $1", []any{"AAPL"}},
+		{url.Values{"ticker_in": {"AAPL", "MSFT"}}, "ticker = ANY($1)", []any{[]string{"AAPL", "MSFT"}}},
+		{url.Values{"brokerage": {"Morgan", "100%"}}, brokerage("LIKE ANY($1)"), []any{[]string{"%Morgan%", `%100\%%`}}},
+		{url.Values{"brokerage_in": {"Morgan Stanley"}}, brokerage("= $1"), []any{"Morgan Stanley"}},
+		{url.Values{"rating_to": {"Buy"}}, "rating_to = $1", []any{"Buy"}},
+		{url.Values{"rating_to_in": {"Buy", "Outperform"}}, "rating_to = ANY($1)", []any{[]string{"Buy", "Outperform"}}},
+		{url.Values{"action": {"raised"}, "action_in": {"target raised by"}}, "action LIKE $1 AND action = $2", []any{"%raised%", "target raised by"}},
+	}
+	for _, test := range tests {
+		builder, err := getWhereClause(test.params)
+		if err != nil {
+			t.Fatalf("params %v: %v", test.params, err)
+		}
+		if got := builder.whereClause(); got != " WHERE "+test.want {
+			t.Errorf("params %v: where = %q, want %q", test.params, got, " WHERE "+test.want)
+		}
+		if fmt.Sprint(builder.args) != fmt.Sprint(test.args) {
+			t.Errorf("params %v: args = %v, want %v", test.params, builder.args, test.args)
+		}
+	}
+}
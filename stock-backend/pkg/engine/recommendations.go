@@ -8,23 +8,19 @@ import (
 	"time"
 )
 
-func GetDBRecommendations() (Recommendation, error) {
+// promptFields son los campos de cada stock que se envían a OpenAI: las variables que lista el prompt
+// y lo necesario para identificar la calificación. El resto (scores, brokerage_id, calculados) solo
+// agranda el prompt.
+var promptFields = []string{"ticker", "company", "brokerage", "action", "rating_from", "rating_to", "target_from", "target_to", "record_time"}
 
-	db, err := connectToDB()
-	if err != nil {
-		log.Fatalf("cannot connect: %v", err)
-	}
+func GetDBRecommendations() (Recommendation, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := db.Ping(ctx); err != nil {
-		log.Fatalf("cannot connect: %v", err)
-	}
+	query := "SELECT " + stockColumns + " FROM stocks WHERE target_to > target_from order by record_time desc limit 50"
 
-	query := "SELECT code, ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, record_time, created_at, updated_at FROM stocks WHERE target_to > target_from order by record_time desc limit 50"
-
-	rows, err := db.Query(ctx, query)
+	rows, err := pool.Query(ctx, query)
 	if err != nil {
 		log.Printf("query error: %v", err)
 		return Recommendation{}, err
@@ -33,21 +29,7 @@ func GetDBRecommendations() (Recommendation, error) {
 
 	var stocks []Stock
 	for rows.Next() {
-		var stock Stock
-		err := rows.Scan(
-			&stock.Code,
-			&stock.Ticker,
-			&stock.Company,
-			&stock.Brokerage,
-			&stock.Action,
-			&stock.RatingFrom,
-			&stock.RatingTo,
-			&stock.TargetFrom,
-			&stock.TargetTo,
-			&stock.RecordTime,
-			&stock.CreatedAt,
-			&stock.UpdatedAt,
-		)
+		stock, err := scanStock(rows)
 		if err != nil {
 			log.Printf("scan error: %v", err)
 			continue
@@ -84,7 +66,11 @@ func GetOpenAIRecommendations(stocks []Stock) (Recommendation, error) {
 		`,
 	})
 
-	jsonStocks, err := json.Marshal(stocks)
+	promptStocks, err := projectStocks(stocks, promptFields)
+	if err != nil {
+		return Recommendation{}, err
+	}
+	jsonStocks, err := json.Marshal(promptStocks)
 	if err != nil {
 		return Recommendation{}, err
 	}
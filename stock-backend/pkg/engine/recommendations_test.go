@@ -0,0 +1,14 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPromptFieldsAreStockFields(t *testing.T) {
+	for _, field := range promptFields {
+		if !slices.Contains(stockFields, field) {
+			t.Errorf("promptFields incluye %s, que no es campo de Stock", field)
+		}
+	}
+}
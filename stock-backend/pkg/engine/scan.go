@@ -0,0 +1,18 @@
+package engine
+
+import (
+	"stock/common/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Columnas que se leen para armar un Stock, incluyendo el nombre canónico del brokerage
+// y los campos calculados
+const stockColumns = model.Columns + ", " + model.BrokerageNameColumn + ", " + model.ComputedColumns
+
+func scanStock(rows pgx.Rows) (Stock, error) {
+	var stock Stock
+	fields := append(stock.Fields(), &stock.BrokerageName)
+	err := rows.Scan(append(fields, stock.ComputedFields()...)...)
+	return stock, err
+}
@@ -0,0 +1,16 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"stock/common/migrations"
+)
+
+// CheckSchemaVersion verifica que la base esté migrada a la última versión del módulo compartido
+func CheckSchemaVersion() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return migrations.CheckVersion(ctx, pool, migrations.Latest())
+}
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// sortNilLast ordena items por value, dejando al final los que no tienen valor
+// (sin importar el sentido) y desempatando por key
+func sortNilLast[T any](items []T, value func(T) *float64, key func(T) string, asc bool) {
+	slices.SortFunc(items, func(a, b T) int {
+		valueA, valueB := value(a), value(b)
+		switch {
+		case valueA == nil && valueB == nil:
+			return strings.Compare(key(a), key(b))
+		case valueA == nil:
+			return 1
+		case valueB == nil:
+			return -1
+		}
+		order := cmp.Compare(*valueA, *valueB)
+		if !asc {
+			order = -order
+		}
+		if order == 0 {
+			return strings.Compare(key(a), key(b))
+		}
+		return order
+	})
+}
+
+func floatPtr(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortNilLast(t *testing.T) {
+	items := []Consensus{
+		{Ticker: "NIL2"},
+		{Ticker: "B", UpsidePct: floatPtr(10)},
+		{Ticker: "NIL1"},
+		{Ticker: "A", UpsidePct: floatPtr(10)},
+		{Ticker: "C", UpsidePct: floatPtr(-5)},
+		{Ticker: "D", UpsidePct: floatPtr(30)},
+	}
+	value := func(c Consensus) *float64 { return c.UpsidePct }
+	key := func(c Consensus) string { return c.Ticker }
+
+	tests := []struct {
+		asc  bool
+		want []string
+	}{
+		{false, []string{"D", "A", "B", "C", "NIL1", "NIL2"}},
+		{true, []string{"C", "A", "B", "D", "NIL1", "NIL2"}},
+	}
+	for _, test := range tests {
+		sorted := slices.Clone(items)
+		sortNilLast(sorted, value, key, test.asc)
+
+		var got []string
+		for _, c := range sorted {
+			got = append(got, c.Ticker)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("asc=%v: %v, want %v", test.asc, got, test.want)
+		}
+	}
+}
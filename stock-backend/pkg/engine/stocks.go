@@ -4,108 +4,287 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"net/url"
+	"os"
 	"slices"
 	"strconv"
+	"sync"
 	"time"
+
+	"stock/common/model"
 )
 
-func getOrderByClause(params map[string]string) string {
-	orderClause := " ORDER BY created_at DESC"
-	if params["order_by"] != "" {
-		orderbyName := params["order_by"]
-		// Solo si orderbyName esta en la lista de campos permitidos de "record_time", "created_at", "ticker", "company", "brokerage", "action", "rating_from", "rating_to", "target_from", "target_to"
-		allowedFields := []string{"record_time", "created_at", "ticker", "company", "brokerage", "action", "rating_from", "rating_to", "target_from", "target_to"}
-		if !slices.Contains(allowedFields, orderbyName) {
-			return orderClause
-		}
-		ascOrDesc := params["asc"]
-		if ascOrDesc == "1" {
-			orderClause = " ORDER BY " + orderbyName + " ASC"
+// getOrder devuelve la columna de orden pedida y si es ascendente; por defecto created_at descendente
+func getOrder(params url.Values) (string, bool) {
+	orderBy := params.Get("order_by")
+	if _, ok := orderColumns[orderBy]; !ok {
+		return "created_at", false
+	}
+	return orderBy, params.Get("asc") == "1"
+}
+
+// maxPerPage es el tamaño de página máximo del servidor; MAX_PER_PAGE se lee una sola vez
+var maxPerPage = sync.OnceValue(func() int {
+	perPage := 100
+	if env := os.Getenv("MAX_PER_PAGE"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			perPage = parsed
 		} else {
-			orderClause = " ORDER BY " + orderbyName + " DESC"
+			log.Printf("MAX_PER_PAGE inválido (%q), se usa %d", env, perPage)
 		}
 	}
-	return orderClause
-}
+	return perPage
+})
 
-func getWhereClause(params map[string]string) string {
+// getPerPage valida per_page; los valores mayores al máximo del servidor se recortan a MAX_PER_PAGE
+func getPerPage(value string) (int, error) {
+	if value == "" {
+		return min(20, maxPerPage()), nil
+	}
+	perPage, err := strconv.Atoi(value)
+	if err != nil || perPage < 1 {
+		return 0, &ParamError{Param: "per_page", Message: "debe ser un entero mayor que 0"}
+	}
+	return min(perPage, maxPerPage()), nil
+}
 
-	// Construir WHERE clause
-	whereClause := " WHERE 1=1"
+// rangeFilters son los filtros de rango: el parámetro, la condición y cómo se interpreta el valor
+var rangeFilters = []struct {
+	param     string
+	condition string
+	parse     func(string) (any, error)
+}{
+	{"target_to_min", "target_to >= ", parseNumber},
+	{"target_to_max", "target_to <= ", parseNumber},
+	{"target_from_min", "target_from >= ", parseNumber},
+	{"target_from_max", "target_from <= ", parseNumber},
+	{"record_time_from", "record_time >= ", parseTimeFrom},
+	{"record_time_to", "record_time <= ", parseTimeTo},
+	{"created_after", "created_at > ", parseTimeFrom},
+	{"target_change_pct_min", model.TargetChangePctExpr + " >= ", parseNumber},
+	{"target_change_pct_max", model.TargetChangePctExpr + " <= ", parseNumber},
+	{"target_change_abs_min", model.TargetChangeAbsExpr + " >= ", parseNumber},
+	{"target_change_abs_max", model.TargetChangeAbsExpr + " <= ", parseNumber},
+	{"rating_delta_min", model.RatingDeltaExpr + "::FLOAT8 >= ", parseNumber},
+	{"rating_delta_max", model.RatingDeltaExpr + "::FLOAT8 <= ", parseNumber},
+}
 
-	if params["ticker"] != "" {
-		whereClause += fmt.Sprintf(" AND ticker like '%%%s%%'", params["ticker"])
+func parseNumber(value string) (any, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return nil, fmt.Errorf("%q no es un número", value)
 	}
+	return number, nil
+}
 
-	if params["brokerage"] != "" {
-		whereClause += fmt.Sprintf(" AND brokerage like '%%%s%%'", params["brokerage"])
+// parseTime acepta fechas ISO-8601 con hora y zona (2025-07-17T00:30:07Z), sin zona (se asume UTC)
+// o solo la fecha (2025-07-17); dateOnly indica este último caso
+func parseTime(value string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, false, nil
 	}
-
-	if params["action"] != "" {
-		whereClause += fmt.Sprintf(" AND action like '%%%s%%'", params["action"])
+	if t, err := time.Parse("2006-01-02T15:04:05.999999999", value); err == nil {
+		return t, false, nil
 	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q no es una fecha ISO-8601 (por ejemplo 2025-07-17 o 2025-07-17T00:30:07Z)", value)
+}
 
-	if params["rating_from"] != "" {
-		whereClause += fmt.Sprintf(" AND rating_from = '%s'", params["rating_from"])
+func parseTimeFrom(value string) (any, error) {
+	t, _, err := parseTime(value)
+	if err != nil {
+		return nil, err
 	}
+	return t, nil
+}
 
-	if params["rating_to"] != "" {
-		whereClause += fmt.Sprintf(" AND rating_to = '%s'", params["rating_to"])
+// parseTimeTo incluye el día completo cuando solo viene la fecha
+func parseTimeTo(value string) (any, error) {
+	t, dateOnly, err := parseTime(value)
+	if err != nil {
+		return nil, err
+	}
+	if dateOnly {
+		t = t.Add(24*time.Hour - time.Microsecond)
 	}
+	return t, nil
+}
 
-	return whereClause
+// textFilter es un filtro sobre una columna de texto; condition arma la condición
+// a partir de la comparación (por ejemplo "LIKE $1" o "= ANY($1)")
+type textFilter struct {
+	param string
+	// contains indica que los valores del parámetro se buscan como subcadena en lugar de igualdad;
+	// param_in compara exacto en ambos casos
+	contains  bool
+	condition func(match string) string
 }
 
-func GetStocks(params map[string]string) (PaginatedStocksResponse, error) {
+func columnCondition(name string) func(string) string {
+	return func(match string) string { return name + " " + match }
+}
 
-	db, err := connectToDB()
-	if err != nil {
-		log.Fatalf("cannot connect: %v", err)
+var textFilters = []textFilter{
+	{"ticker", true, columnCondition("ticker")},
+	// Coincide con el nombre recibido de la API o con el nombre canónico del registro
+	{"brokerage", true, func(match string) string {
+		return "(brokerage " + match + " OR brokerage_id IN (SELECT id FROM brokerages WHERE name " + match + "))"
+	}},
+	{"action", true, columnCondition("action")},
+	{"rating_from", false, columnCondition("rating_from")},
+	{"rating_to", false, columnCondition("rating_to")},
+	{"direction", false, columnCondition(model.DirectionExpr)},
+}
+
+// paramValues devuelve los valores no vacíos de un parámetro
+func paramValues(params url.Values, key string) []string {
+	var result []string
+	for _, value := range params[key] {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// getWhereClause traduce los filtros recibidos a condiciones con placeholders;
+// el mismo builder se usa para el conteo y para la página.
+//
+// Los filtros de texto aceptan uno o varios valores repitiendo el parámetro y la fila debe coincidir
+// con alguno: ticker, brokerage y action los buscan como subcadena y el resto por igualdad.
+// Con el sufijo "_in" la comparación es siempre exacta (ticker_in=AAPL, IN) y con el prefijo "-"
+// se excluyen valores exactos (-action=reiterated by, NOT IN).
+func getWhereClause(params url.Values) (*queryBuilder, error) {
+
+	filter := &queryBuilder{}
+
+	for _, text := range textFilters {
+		if included := paramValues(params, text.param); len(included) > 0 {
+			if text.contains {
+				filter.where(text.condition(filter.likeMatch(included)))
+			} else {
+				filter.where(text.condition(filter.exactMatch(included)))
+			}
+		}
+		if included := paramValues(params, text.param+"_in"); len(included) > 0 {
+			filter.where(text.condition(filter.exactMatch(included)))
+		}
+
+		// IS NOT TRUE conserva las filas donde la columna es NULL
+		if excluded := paramValues(params, "-"+text.param); len(excluded) > 0 {
+			filter.where("(" + text.condition("= ANY("+filter.arg(excluded)+")") + ") IS NOT TRUE")
+		}
 	}
 
+	for _, bound := range rangeFilters {
+		if params.Get(bound.param) == "" {
+			continue
+		}
+		value, err := bound.parse(params.Get(bound.param))
+		if err != nil {
+			return nil, &ParamError{Param: bound.param, Message: err.Error()}
+		}
+		filter.where(bound.condition + filter.arg(value))
+	}
+
+	return filter, nil
+}
+
+// GetStocks devuelve una página de stocks filtrados.
+//
+// Además de los filtros de texto admite rangos: target_to_min, target_to_max,
+// target_from_min, target_from_max, record_time_from, record_time_to, created_after
+// y los de los campos calculados (target_change_pct, target_change_abs, rating_delta con _min y _max).
+//
+// Hay dos formas de paginar:
+//   - page: paginación por offset, la que usa el frontend; incluye el total por defecto
+//   - cursor: el token next_cursor o prev_cursor de una respuesta anterior; continúa desde
+//     esa fila sin importar lo que se haya insertado entretanto. El orden lo define el cursor.
+//     El total solo se calcula si se pide con total=1.
+//
+// total=0 evita el COUNT(*) también en la paginación por offset.
+//
+// per_page fija el tamaño de página (por defecto 20, como máximo MAX_PER_PAGE, por defecto 100)
+// y fields= la lista de campos de cada stock separados por comas.
+func GetStocks(params url.Values) (PaginatedStocksResponse, error) {
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := db.Ping(ctx); err != nil {
-		log.Fatalf("cannot connect: %v", err)
+	filter, err := getWhereClause(params)
+	if err != nil {
+		return PaginatedStocksResponse{}, err
 	}
 
-	whereClause := getWhereClause(params)
+	orderBy, asc := getOrder(params)
 
-	orderClause := getOrderByClause(params)
+	fields, err := parseFields(params.Get("fields"))
+	if err != nil {
+		return PaginatedStocksResponse{}, err
+	}
 
 	// Paginación
-	var page int
-	perPage := 20
-	if params["page"] == "" {
-		page = 1
-	} else {
-		page, err = strconv.Atoi(params["page"])
+	page := 1
+	perPage, err := getPerPage(params.Get("per_page"))
+	if err != nil {
+		return PaginatedStocksResponse{}, err
+	}
+	var position *cursor
+	if params.Get("cursor") != "" {
+		c, err := decodeCursor(params.Get("cursor"))
 		if err != nil {
-			log.Printf("page error: %v", err)
 			return PaginatedStocksResponse{}, err
 		}
+		position = &c
+		orderBy, asc = c.OrderBy, c.Asc
+	} else if params.Get("page") != "" {
+		page, err = strconv.Atoi(params.Get("page"))
+		if err != nil || page < 1 {
+			return PaginatedStocksResponse{}, &ParamError{Param: "page", Message: "debe ser un entero mayor que 0"}
+		}
 	}
 
-	// Consulta para obtener el total
-	countQuery := "SELECT COUNT(*) FROM stocks" + whereClause
-	var total int
-	err = db.QueryRow(ctx, countQuery).Scan(&total)
-	if err != nil {
-		log.Printf("count query error: %v", err)
-		return PaginatedStocksResponse{}, err
+	withTotal := position == nil
+	if params.Get("total") != "" {
+		withTotal = params.Get("total") == "1"
 	}
 
-	// Consulta principal con paginación
-	query := "SELECT code, ticker, company, brokerage, action, rating_from, rating_to, target_from, target_to, record_time, created_at, updated_at FROM stocks"
-	query += whereClause
-	query += orderClause
-	query += fmt.Sprintf(" LIMIT %d OFFSET %d", perPage, (page-1)*perPage)
+	// Consulta para obtener el total, con los filtros pero sin la posición del cursor
+	var total *int
+	if withTotal {
+		countQuery := "SELECT COUNT(*) FROM stocks" + filter.whereClause()
+		var count int
+		if err := pool.QueryRow(ctx, countQuery, filter.args...).Scan(&count); err != nil {
+			log.Printf("count query error: %v", err)
+			return PaginatedStocksResponse{}, err
+		}
+		total = &count
+	}
+
+	// Consulta principal; se pide una fila de más para saber si hay otra página.
+	// Para ir hacia atrás se invierte el orden y luego se voltea el resultado.
+	query := "SELECT " + stockColumns + " FROM stocks"
+	backwards := position != nil && position.Prev
+	if position != nil {
+		if err := filter.seek(*position); err != nil {
+			return PaginatedStocksResponse{}, err
+		}
+		query += filter.whereClause()
+		query += orderByClause(orderBy, asc != backwards)
+		query += " LIMIT " + filter.arg(perPage+1)
+	} else {
+		query += filter.whereClause()
+		query += orderByClause(orderBy, asc)
+		query += " LIMIT " + filter.arg(perPage+1) + " OFFSET " + filter.arg((page-1)*perPage)
+	}
 
 	fmt.Println(query)
 	var stocks []Stock
 
-	rows, err := db.Query(ctx, query)
+	rows, err := pool.Query(ctx, query, filter.args...)
 	if err != nil {
 		log.Printf("query error: %v", err)
 		return PaginatedStocksResponse{}, err
@@ -113,21 +292,7 @@ func GetStocks(params map[string]string) (PaginatedStocksResponse, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var stock Stock
-		err := rows.Scan(
-			&stock.Code,
-			&stock.Ticker,
-			&stock.Company,
-			&stock.Brokerage,
-			&stock.Action,
-			&stock.RatingFrom,
-			&stock.RatingTo,
-			&stock.TargetFrom,
-			&stock.TargetTo,
-			&stock.RecordTime,
-			&stock.CreatedAt,
-			&stock.UpdatedAt,
-		)
+		stock, err := scanStock(rows)
 		if err != nil {
 			log.Printf("scan error: %v", err)
 			continue
@@ -140,19 +305,44 @@ func GetStocks(params map[string]string) (PaginatedStocksResponse, error) {
 		return PaginatedStocksResponse{}, err
 	}
 
-	// Calcular página siguiente
-	var nextPage *int
-	if page*perPage < total {
-		next := page + 1
-		nextPage = &next
+	hasMore := len(stocks) > perPage
+	if hasMore {
+		stocks = stocks[:perPage]
+	}
+	if backwards {
+		slices.Reverse(stocks)
 	}
 
 	response := PaginatedStocksResponse{
-		Stocks:      stocks,
-		CurrentPage: page,
-		NextPage:    nextPage,
-		Total:       total,
-		PerPage:     perPage,
+		Stocks:  stocks,
+		Total:   total,
+		PerPage: perPage,
+		Fields:  fields,
+	}
+
+	// Hay filas antes si se llegó con un cursor hacia adelante, si se retrocedió y sobró
+	// una fila, o si no es la primera página por offset
+	hasNext := hasMore
+	hasPrev := position != nil || page > 1
+	if backwards {
+		hasNext, hasPrev = true, hasMore
+	}
+	if len(stocks) > 0 {
+		if hasNext {
+			response.NextCursor = newCursor(orderBy, asc, stocks[len(stocks)-1], false)
+		}
+		if hasPrev {
+			response.PrevCursor = newCursor(orderBy, asc, stocks[0], true)
+		}
+	}
+
+	// Página siguiente para la paginación por offset
+	if position == nil {
+		response.CurrentPage = page
+		if hasMore {
+			next := page + 1
+			response.NextPage = &next
+		}
 	}
 
 	return response, nil
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+
+	"stock/backend/pkg/engine"
+)
+
+// GetBrokeragesHandler devuelve el ranking de brokerages por actividad
+func GetBrokeragesHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	brokerages, err := engine.GetBrokerages(r.URL.Query())
+	if err != nil {
+		throwEngineError(w, err)
+		return
+	}
+
+	writeSuccess(w, brokerages)
+}
+
+// GetBrokerageHandler devuelve las estadísticas, la actividad por período y los tickers de un brokerage
+func GetBrokerageHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	// Los nombres suelen traer espacios o "&", que pueden llegar codificados en la ruta
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+	if err != nil {
+		name = chi.URLParam(r, "name")
+	}
+
+	brokerage, err := engine.GetBrokerage(name, r.URL.Query())
+	if err != nil {
+		throwEngineError(w, err)
+		return
+	}
+
+	writeSuccess(w, brokerage)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"stock/backend/pkg/engine"
+	"stock/backend/pkg/exceptions"
+)
+
+// writeSuccess responde con el formato {"message": "Success", "data": ...}
+func writeSuccess(w http.ResponseWriter, data any) {
+	payloadResponse := map[string]interface{}{
+		"message": "Success",
+		"data":    data,
+	}
+
+	response, err := json.Marshal(payloadResponse)
+	if err != nil {
+		exceptions.Throw(w, exceptions.AppException{Detail: "Error generando respuesta"}, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Write(response)
+}
+
+// throwEngineError traduce los errores del engine: 400 para parámetros inválidos,
+// 404 si no existe lo pedido y 500 para el resto
+func throwEngineError(w http.ResponseWriter, err error) {
+	var paramErr *engine.ParamError
+	switch {
+	case errors.As(err, &paramErr):
+		exceptions.Throw(w, exceptions.AppException{Detail: paramErr.Error()}, http.StatusBadRequest, err)
+	case errors.Is(err, engine.ErrNotFound):
+		exceptions.Throw(w, exceptions.AppException{Detail: err.Error()}, http.StatusNotFound, err)
+	default:
+		exceptions.Throw(w, exceptions.AppException{Detail: "Error generando respuesta"}, http.StatusInternalServerError, err)
+	}
+}
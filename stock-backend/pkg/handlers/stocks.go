@@ -1,51 +1,56 @@
 package handlers
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+
 	"stock/backend/pkg/engine"
-	"stock/backend/pkg/exceptions"
 )
 
 func GetStocksHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	brokerage := r.URL.Query().Get("brokerage")
-	action := r.URL.Query().Get("action")
-	ratingFrom := r.URL.Query().Get("rating_from")
-	ratingTo := r.URL.Query().Get("rating_to")
-	page := r.URL.Query().Get("page")
-	ticker := r.URL.Query().Get("ticker")
-	order_by := r.URL.Query().Get("order_by")
-	asc := r.URL.Query().Get("asc")
-	mapParams := map[string]string{
-		"ticker":      ticker,
-		"brokerage":   brokerage,
-		"action":      action,
-		"rating_from": ratingFrom,
-		"rating_to":   ratingTo,
-		"page":        page,
-		"order_by":    order_by,
-		"asc":         asc,
+	params := r.URL.Query()
+	if params.Get("per_page") == "" {
+		// El store del frontend envía pageSize
+		params.Set("per_page", params.Get("pageSize"))
 	}
-	stocksResponse, err := engine.GetStocks(mapParams)
+	stocksResponse, err := engine.GetStocks(params)
 	if err != nil {
-		exceptions.Throw(w, exceptions.AppException{Detail: "Error generando respuesta"}, http.StatusInternalServerError, err)
+		throwEngineError(w, err)
 		return
 	}
 
-	payloadResponse := map[string]interface{}{
-		"message": "Success",
-		"data":    stocksResponse,
+	writeSuccess(w, stocksResponse)
+}
+
+// GetStockHandler devuelve una calificación por su code
+func GetStockHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	stock, err := engine.GetStock(chi.URLParam(r, "code"))
+	if err != nil {
+		throwEngineError(w, err)
+		return
 	}
 
-	response, err := json.Marshal(payloadResponse)
+	writeSuccess(w, stock)
+}
+
+// GetStockFacetsHandler devuelve los valores de brokerage, action y ratings con su cantidad,
+// respetando los mismos filtros que /stocks/list
+func GetStockFacetsHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	facets, err := engine.GetFacets(r.URL.Query())
 	if err != nil {
-		exceptions.Throw(w, exceptions.AppException{Detail: "Error generando respuesta"}, http.StatusInternalServerError, err)
+		throwEngineError(w, err)
 		return
 	}
 
-	w.Write(response)
+	writeSuccess(w, facets)
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"stock/backend/pkg/engine"
+)
+
+// GetTickerHistoryHandler devuelve todas las calificaciones de un ticker en orden cronológico
+func GetTickerHistoryHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	history, err := engine.GetTickerHistory(chi.URLParam(r, "ticker"))
+	if err != nil {
+		throwEngineError(w, err)
+		return
+	}
+
+	writeSuccess(w, history)
+}
+
+// GetTickerConsensusHandler devuelve el consenso de targets y ratings de un ticker
+func GetTickerConsensusHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	consensus, err := engine.GetTickerConsensus(chi.URLParam(r, "ticker"), r.URL.Query())
+	if err != nil {
+		throwEngineError(w, err)
+		return
+	}
+
+	writeSuccess(w, consensus)
+}
+
+// GetConsensusListHandler devuelve el consenso de todos los tickers, ordenado por upside por defecto
+func GetConsensusListHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	consensus, err := engine.GetConsensusList(r.URL.Query())
+	if err != nil {
+		throwEngineError(w, err)
+		return
+	}
+
+	writeSuccess(w, consensus)
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Config son los datos de conexión compartidos por el getter y el backend
+type Config struct {
+	User     string
+	Password string
+	Host     string
+	Port     string
+	Database string
+	// SSLMode es el sslmode de la conexión; para un Postgres local sin TLS usar "disable"
+	SSLMode string
+
+	// Parámetros del pool (ver NewPool)
+	MinConns          int32
+	MaxConns          int32
+	HealthCheckPeriod time.Duration
+}
+
+// ConfigFromEnv lee DB_USER, DB_PASSWORD, DB_HOST, DB_PORT, DB_DATABASE y DB_SSLMODE (por defecto require),
+// y para el pool DB_MIN_CONNS (2), DB_MAX_CONNS (10) y DB_HEALTH_CHECK_PERIOD (30s)
+func ConfigFromEnv() Config {
+	sslMode := os.Getenv("DB_SSLMODE")
+	if sslMode == "" {
+		sslMode = "require"
+	}
+
+	return Config{
+		User:              os.Getenv("DB_USER"),
+		Password:          os.Getenv("DB_PASSWORD"),
+		Host:              os.Getenv("DB_HOST"),
+		Port:              os.Getenv("DB_PORT"),
+		Database:          os.Getenv("DB_DATABASE"),
+		SSLMode:           sslMode,
+		MinConns:          int32(envInt("DB_MIN_CONNS", 2)),
+		MaxConns:          int32(envInt("DB_MAX_CONNS", 10)),
+		HealthCheckPeriod: envDuration("DB_HEALTH_CHECK_PERIOD", 30*time.Second),
+	}
+}
+
+// URL arma la cadena de conexión postgresql://
+func (c Config) URL() string {
+	host := c.Host
+	if c.Port != "" {
+		host += ":" + c.Port
+	}
+	connURL := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     host,
+		Path:     "/" + c.Database,
+		RawQuery: "sslmode=" + url.QueryEscape(c.SSLMode),
+	}
+	return connURL.String()
+}
+
+// Connect abre una conexión individual, útil para comandos de corta duración
+func Connect(ctx context.Context, config Config) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, config.URL())
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+	return conn, nil
+}
+
+// NewPool crea un pool de conexiones y verifica que la base responda
+func NewPool(ctx context.Context, config Config) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.URL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid database config: %w", err)
+	}
+	poolConfig.MinConns = config.MinConns
+	poolConfig.MaxConns = config.MaxConns
+	poolConfig.HealthCheckPeriod = config.HealthCheckPeriod
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("error connecting to database: %w", err)
+	}
+	return pool, nil
+}
+
+func envInt(name string, defaultValue int) int {
+	parsed, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || parsed <= 0 {
+		return defaultValue
+	}
+	return parsed
+}
+
+func envDuration(name string, defaultValue time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || parsed <= 0 {
+		return defaultValue
+	}
+	return parsed
+}
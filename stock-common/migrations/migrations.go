@@ -0,0 +1,242 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Los archivos se nombran <versión>_<nombre>.up.sql y <versión>_<nombre>.down.sql
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// Migration es un cambio de esquema versionado
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus indica si una migración ya se aplicó y cuándo
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// DB es lo que necesitan las migraciones de una conexión; lo cumplen *pgx.Conn y *pgxpool.Pool
+type DB interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INT PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL
+)`
+
+// All devuelve las migraciones embebidas ordenadas por versión
+func All() ([]Migration, error) {
+	entries, err := files.ReadDir("sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		versionText, migrationName, found := strings.Cut(base, "_")
+		if !ok || !found || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("nombre de migración inválido: %s", name)
+		}
+		version, err := strconv.Atoi(versionText)
+		if err != nil {
+			return nil, fmt.Errorf("versión inválida en %s: %w", name, err)
+		}
+
+		content, err := files.ReadFile(path.Join("sql", name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: migrationName}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("la migración %04d_%s debe tener archivo up y down", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Latest es la versión de esquema que esperan los binarios compilados con estas migraciones
+func Latest() int {
+	migrations, err := All()
+	if err != nil || len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// CurrentVersion devuelve la última versión aplicada en la base (0 si no hay ninguna)
+func CurrentVersion(ctx context.Context, db DB) (int, error) {
+	var version *int
+	err := db.QueryRow(ctx, "SELECT max(version) FROM schema_migrations").Scan(&version)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42P01" {
+		// La tabla schema_migrations aún no existe
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if version == nil {
+		return 0, nil
+	}
+	return *version, nil
+}
+
+// CheckVersion devuelve un error si la base no está exactamente en la versión esperada
+func CheckVersion(ctx context.Context, db DB, expected int) error {
+	current, err := CurrentVersion(ctx, db)
+	if err != nil {
+		return fmt.Errorf("error consultando la versión del esquema: %w", err)
+	}
+	if current != expected {
+		return fmt.Errorf("la base está en la versión de esquema %d y se esperaba la %d, ejecuta `migrate up` en stock-getter", current, expected)
+	}
+	return nil
+}
+
+func applied(ctx context.Context, db DB) (map[int]time.Time, error) {
+	if _, err := db.Exec(ctx, createMigrationsTable); err != nil {
+		return nil, fmt.Errorf("error creando schema_migrations: %w", err)
+	}
+
+	rows, err := db.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		result[version] = appliedAt
+	}
+	return result, rows.Err()
+}
+
+// Status lista todas las migraciones indicando cuáles ya se aplicaron
+func Status(ctx context.Context, db DB) ([]MigrationStatus, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	done, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if appliedAt, ok := done[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up aplica en orden las migraciones pendientes hasta la versión target (0 = todas).
+// Cada migración corre en su propia transacción junto con su registro en schema_migrations.
+func Up(ctx context.Context, db DB, target int) ([]Migration, error) {
+	statuses, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		if target > 0 && status.Version > target {
+			break
+		}
+		err := pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, status.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)", status.Version, status.Name, time.Now())
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("error aplicando la migración %04d_%s: %w", status.Version, status.Name, err)
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
+
+// Down revierte las últimas steps migraciones aplicadas, de la más reciente a la más antigua
+func Down(ctx context.Context, db DB, steps int) ([]Migration, error) {
+	statuses, err := Status(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		status := statuses[i]
+		if status.AppliedAt == nil {
+			continue
+		}
+		err := pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, status.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", status.Version)
+			return err
+		})
+		if err != nil {
+			return done, fmt.Errorf("error revirtiendo la migración %04d_%s: %w", status.Version, status.Name, err)
+		}
+		done = append(done, status.Migration)
+	}
+	return done, nil
+}
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS stocks (
+	code UUID PRIMARY KEY,
+	ticker TEXT,
+	company TEXT,
+	brokerage TEXT,
+	action TEXT,
+	rating_from TEXT,
+	rating_to TEXT,
+	target_from FLOAT8,
+	target_to FLOAT8,
+	record_time TIMESTAMPTZ,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS stocks_record_time_idx ON stocks (record_time);
+CREATE INDEX IF NOT EXISTS stocks_created_at_idx ON stocks (created_at);
+CREATE INDEX IF NOT EXISTS stocks_ticker_idx ON stocks (ticker);
@@ -0,0 +1,2 @@
+ALTER TABLE stocks DROP COLUMN IF EXISTS run_id;
+DROP TABLE IF EXISTS ingest_runs;
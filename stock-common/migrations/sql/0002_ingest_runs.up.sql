@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS ingest_runs (
+	id UUID PRIMARY KEY,
+	command TEXT NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	finished_at TIMESTAMPTZ,
+	pages INT NOT NULL DEFAULT 0,
+	items INT NOT NULL DEFAULT 0,
+	inserted INT NOT NULL DEFAULT 0,
+	updated INT NOT NULL DEFAULT 0,
+	unchanged INT NOT NULL DEFAULT 0,
+	rejected INT NOT NULL DEFAULT 0,
+	status TEXT NOT NULL,
+	error TEXT
+);
+
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS run_id UUID;
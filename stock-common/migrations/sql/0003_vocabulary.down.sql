@@ -0,0 +1,5 @@
+ALTER TABLE ingest_runs DROP COLUMN IF EXISTS unknown;
+ALTER TABLE stocks DROP COLUMN IF EXISTS vocab_unknown;
+ALTER TABLE stocks DROP COLUMN IF EXISTS rating_to_score;
+ALTER TABLE stocks DROP COLUMN IF EXISTS rating_from_score;
+ALTER TABLE stocks DROP COLUMN IF EXISTS action_code;
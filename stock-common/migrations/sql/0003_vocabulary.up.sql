@@ -0,0 +1,5 @@
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS action_code TEXT;
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS rating_from_score INT;
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS rating_to_score INT;
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS vocab_unknown BOOL NOT NULL DEFAULT false;
+ALTER TABLE ingest_runs ADD COLUMN IF NOT EXISTS unknown INT NOT NULL DEFAULT 0;
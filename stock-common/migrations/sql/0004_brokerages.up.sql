@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS brokerages (
+	id UUID PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS brokerage_aliases (
+	alias TEXT PRIMARY KEY,
+	brokerage_id UUID NOT NULL REFERENCES brokerages (id)
+);
+
+ALTER TABLE stocks ADD COLUMN IF NOT EXISTS brokerage_id UUID;
+CREATE INDEX IF NOT EXISTS stocks_brokerage_id_idx ON stocks (brokerage_id);
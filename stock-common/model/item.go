@@ -0,0 +1,92 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockItem es un item tal como lo entrega la API de origen
+type StockItem struct {
+	Ticker     string `json:"ticker"`
+	TargetFrom string `json:"target_from"`
+	TargetTo   string `json:"target_to"`
+	Company    string `json:"company"`
+	Action     string `json:"action"`
+	Brokerage  string `json:"brokerage"`
+	RatingFrom string `json:"rating_from"`
+	RatingTo   string `json:"rating_to"`
+	Time       string `json:"time"`
+}
+
+// StockResponse es una página de la API de origen
+type StockResponse struct {
+	Items    []StockItem `json:"items"`
+	NextPage string      `json:"next_page"`
+}
+
+// Espacio de nombres para derivar el code de una calificación desde su llave natural.
+// No debe cambiar: los codes ya guardados dependen de él.
+var stockNamespace = uuid.MustParse("5b0c6f2e-8d7a-4c55-9a0e-3f1d2b6c7e90")
+
+// StockCode genera un code determinístico a partir de ticker + brokerage + record_time + action,
+// así una misma calificación siempre cae en la misma fila sin importar cuántas veces se descargue
+func StockCode(ticker, brokerage string, recordTime time.Time, action string) uuid.UUID {
+	key := strings.Join([]string{ticker, brokerage, recordTime.UTC().Format(time.RFC3339Nano), action}, "|")
+	return uuid.NewSHA1(stockNamespace, []byte(key))
+}
+
+// ParseTarget convierte un precio como "$1,085.50" a número
+func ParseTarget(value string) (float64, error) {
+	cleaned := strings.ReplaceAll(value, "$", "")
+	cleaned = strings.ReplaceAll(cleaned, ",", "")
+	target, err := strconv.ParseFloat(strings.TrimSpace(cleaned), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(target) || math.IsInf(target, 0) || target < 0 {
+		return 0, fmt.Errorf("invalid target %q", value)
+	}
+	return target, nil
+}
+
+// FromItem valida un item de la API y lo convierte en la fila de stocks correspondiente.
+// Los campos canónicos (action_code, scores, brokerage_id) los completa quien ingiere.
+func FromItem(item StockItem) (Stock, error) {
+	if strings.TrimSpace(item.Ticker) == "" {
+		return Stock{}, errors.New("missing ticker")
+	}
+	targetFrom, err := ParseTarget(item.TargetFrom)
+	if err != nil {
+		return Stock{}, fmt.Errorf("error parsing target from: %w", err)
+	}
+	targetTo, err := ParseTarget(item.TargetTo)
+	if err != nil {
+		return Stock{}, fmt.Errorf("error parsing target to: %w", err)
+	}
+	parsedTime, err := time.Parse(time.RFC3339Nano, item.Time)
+	if err != nil {
+		return Stock{}, fmt.Errorf("error parsing time: %w", err)
+	}
+	// TIMESTAMPTZ guarda microsegundos: se trunca para que la fila leída de la base sea igual a la nueva.
+	// El code se sigue calculando con el tiempo original para no cambiar los ya guardados.
+	recordTime := parsedTime.Truncate(time.Microsecond)
+
+	return Stock{
+		Code:       StockCode(item.Ticker, item.Brokerage, parsedTime, item.Action),
+		Ticker:     &item.Ticker,
+		TargetFrom: &targetFrom,
+		TargetTo:   &targetTo,
+		Company:    &item.Company,
+		Action:     &item.Action,
+		Brokerage:  &item.Brokerage,
+		RatingFrom: &item.RatingFrom,
+		RatingTo:   &item.RatingTo,
+		RecordTime: &recordTime,
+	}, nil
+}
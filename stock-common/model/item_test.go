@@ -0,0 +1,136 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFromItemRoundTripsAtMicrosecondPrecision(t *testing.T) {
+	item := StockItem{
+		Ticker:     "AAPL",
+		TargetFrom: "$200.00",
+		TargetTo:   "$220.00",
+		Company:    "Apple Inc.",
+		Action:     "target raised by",
+		Brokerage:  "Goldman Sachs",
+		RatingFrom: "Buy",
+		RatingTo:   "Buy",
+		Time:       "2025-07-17T00:30:07.155596789Z",
+	}
+	parsed := time.Date(2025, 7, 17, 0, 30, 7, 155596789, time.UTC)
+
+	stock, err := FromItem(item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := parsed.Truncate(time.Microsecond); !stock.RecordTime.Equal(want) {
+		t.Errorf("record_time = %v, want %v", stock.RecordTime, want)
+	}
+	if want := StockCode(item.Ticker, item.Brokerage, parsed, item.Action); stock.Code != want {
+		t.Errorf("code = %v, want %v (calculado con el tiempo original)", stock.Code, want)
+	}
+
+	// La base devuelve TIMESTAMPTZ con microsegundos y en la zona de la sesión
+	stored := stock
+	fromDB := time.Date(2025, 7, 16, 19, 30, 7, 155596000, time.FixedZone("", -5*60*60))
+	stored.RecordTime = &fromDB
+	if !stock.SameContent(stored) {
+		t.Error("la fila leída de la base no coincide con la nueva: se contaría como actualizada")
+	}
+}
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"$1,085.50", 1085.50, false},
+		{"$4.20", 4.20, false},
+		{" 15 ", 15, false},
+		{"$0.00", 0, false},
+		{"", 0, true},
+		{"N/A", 0, true},
+		{"$-5.00", 0, true},
+		{"-5", 0, true},
+		{"NaN", 0, true},
+		{"Inf", 0, true},
+	}
+	for _, test := range tests {
+		got, err := ParseTarget(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseTarget(%q): err = %v, wantErr %v", test.value, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseTarget(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestStockCodeIsStable(t *testing.T) {
+	recordTime := time.Date(2025, 7, 17, 0, 30, 7, 155596789, time.UTC)
+	code := StockCode("AAPL", "Goldman Sachs", recordTime, "target raised by")
+
+	// El code ya guardado no debe cambiar: depende del namespace y del formato de la llave
+	if want := "d2622215-3ae4-5868-8046-2cd0f30fdaa0"; code.String() != want {
+		t.Errorf("code = %s, want %s", code, want)
+	}
+	inOtherZone := recordTime.In(time.FixedZone("", -5*60*60))
+	if got := StockCode("AAPL", "Goldman Sachs", inOtherZone, "target raised by"); got != code {
+		t.Errorf("el code cambia con la zona horaria: %s != %s", got, code)
+	}
+
+	tests := map[string]string{
+		"ticker":    StockCode("MSFT", "Goldman Sachs", recordTime, "target raised by").String(),
+		"brokerage": StockCode("AAPL", "Morgan Stanley", recordTime, "target raised by").String(),
+		"time":      StockCode("AAPL", "Goldman Sachs", recordTime.Add(time.Nanosecond), "target raised by").String(),
+		"action":    StockCode("AAPL", "Goldman Sachs", recordTime, "upgraded by").String(),
+	}
+	for field, got := range tests {
+		if got == code.String() {
+			t.Errorf("cambiar %s no cambia el code", field)
+		}
+	}
+}
+
+func TestFromItem(t *testing.T) {
+	valid := StockItem{
+		Ticker:     "AAPL",
+		TargetFrom: "$1,085.50",
+		TargetTo:   "$1,200.00",
+		Company:    "Apple Inc.",
+		Action:     "target raised by",
+		Brokerage:  "Goldman Sachs",
+		RatingFrom: "Buy",
+		RatingTo:   "Buy",
+		Time:       "2025-07-17T00:30:07.155596Z",
+	}
+
+	stock, err := FromItem(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stock.Ticker != "AAPL" || *stock.TargetFrom != 1085.50 || *stock.TargetTo != 1200 ||
+		*stock.Company != "Apple Inc." || *stock.Action != "target raised by" ||
+		*stock.Brokerage != "Goldman Sachs" || *stock.RatingFrom != "Buy" || *stock.RatingTo != "Buy" {
+		t.Errorf("FromItem = %+v", stock)
+	}
+	if stock.BrokerageID != nil || stock.ActionCode != nil || stock.RatingToScore != nil {
+		t.Errorf("los campos canónicos los completa quien ingiere: %+v", stock)
+	}
+
+	tests := map[string]func(*StockItem){
+		"sin ticker":         func(item *StockItem) { item.Ticker = " " },
+		"target_from vacío":  func(item *StockItem) { item.TargetFrom = "" },
+		"target_to inválido": func(item *StockItem) { item.TargetTo = "N/A" },
+		"time inválido":      func(item *StockItem) { item.Time = "2025-07-17" },
+	}
+	for name, change := range tests {
+		item := valid
+		change(&item)
+		if _, err := FromItem(item); err == nil {
+			t.Errorf("%s: se esperaba error", name)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stock es una fila de la tabla stocks: una calificación de un brokerage sobre un ticker.
+// Los punteros corresponden a columnas que pueden ser NULL.
+type Stock struct {
+	Code            uuid.UUID  `json:"code"`
+	Ticker          *string    `json:"ticker"`
+	Company         *string    `json:"company"`
+	Brokerage       *string    `json:"brokerage"`
+	BrokerageID     *uuid.UUID `json:"brokerage_id"`
+	BrokerageName   *string    `json:"brokerage_name"`
+	Action          *string    `json:"action"`
+	ActionCode      *string    `json:"action_code"`
+	RatingFrom      *string    `json:"rating_from"`
+	RatingFromScore *int       `json:"rating_from_score"`
+	RatingTo        *string    `json:"rating_to"`
+	RatingToScore   *int       `json:"rating_to_score"`
+	TargetFrom      *float64   `json:"target_from"`
+	TargetTo        *float64   `json:"target_to"`
+	RecordTime      *time.Time `json:"record_time"`
+	CreatedAt       *time.Time `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+	VocabUnknown    bool       `json:"vocab_unknown"`
+	RunID           *uuid.UUID `json:"-"`
+
+	// Campos calculados al leer a partir de targets y ratings (ver ComputedColumns)
+	TargetChangePct *float64 `json:"target_change_pct"`
+	TargetChangeAbs *float64 `json:"target_change_abs"`
+	RatingDelta     *int     `json:"rating_delta"`
+	Direction       string   `json:"direction"`
+}
+
+// Columns son las columnas de la tabla stocks en el orden de Fields.
+// brokerage_name no es columna de stocks: se obtiene del registro de brokerages (ver BrokerageNameColumn).
+const Columns = "code, ticker, company, brokerage, brokerage_id, action, action_code, rating_from, rating_from_score, rating_to, rating_to_score, target_from, target_to, record_time, created_at, updated_at, vocab_unknown, run_id"
+
+// BrokerageNameColumn agrega el nombre canónico del brokerage a un SELECT sobre stocks
+const BrokerageNameColumn = "(SELECT name FROM brokerages WHERE id = stocks.brokerage_id) AS brokerage_name"
+
+// Expresiones de los campos calculados. target_change_pct es el cambio del target en
+// porcentaje ((target_to - target_from) / target_from * 100), rating_delta la diferencia
+// en la escala normalizada (1 a 5) y direction upgrade, downgrade o neutral según rating_delta,
+// o según action_code cuando el rating no está en el vocabulario.
+const (
+	TargetChangePctExpr = "((target_to - target_from) / NULLIF(target_from, 0) * 100)"
+	TargetChangeAbsExpr = "(target_to - target_from)"
+	RatingDeltaExpr     = "(rating_to_score - rating_from_score)"
+	DirectionExpr       = "(CASE WHEN rating_to_score > rating_from_score THEN 'upgrade'" +
+		" WHEN rating_to_score < rating_from_score THEN 'downgrade'" +
+		" WHEN rating_to_score IS NULL OR rating_from_score IS NULL THEN" +
+		" (CASE WHEN action_code IN ('upgrade', 'downgrade') THEN action_code ELSE 'neutral' END)" +
+		" ELSE 'neutral' END)"
+)
+
+// ComputedColumns agrega los campos calculados a un SELECT sobre stocks, en el orden de ComputedFields
+const ComputedColumns = TargetChangePctExpr + " AS target_change_pct, " +
+	TargetChangeAbsExpr + " AS target_change_abs, " +
+	RatingDeltaExpr + " AS rating_delta, " +
+	DirectionExpr + " AS direction"
+
+// Fields devuelve los destinos para Scan en el orden de Columns
+func (s *Stock) Fields() []any {
+	return []any{
+		&s.Code,
+		&s.Ticker,
+		&s.Company,
+		&s.Brokerage,
+		&s.BrokerageID,
+		&s.Action,
+		&s.ActionCode,
+		&s.RatingFrom,
+		&s.RatingFromScore,
+		&s.RatingTo,
+		&s.RatingToScore,
+		&s.TargetFrom,
+		&s.TargetTo,
+		&s.RecordTime,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+		&s.VocabUnknown,
+		&s.RunID,
+	}
+}
+
+// ComputedFields devuelve los destinos para Scan en el orden de ComputedColumns
+func (s *Stock) ComputedFields() []any {
+	return []any{
+		&s.TargetChangePct,
+		&s.TargetChangeAbs,
+		&s.RatingDelta,
+		&s.Direction,
+	}
+}
+
+// SameContent compara los datos de la calificación ignorando las marcas de auditoría
+// (created_at, updated_at, run_id), el nombre canónico y los campos calculados al leer
+func (s Stock) SameContent(other Stock) bool {
+	return s.Code == other.Code &&
+		equal(s.Ticker, other.Ticker) &&
+		equal(s.Company, other.Company) &&
+		equal(s.Brokerage, other.Brokerage) &&
+		equal(s.BrokerageID, other.BrokerageID) &&
+		equal(s.Action, other.Action) &&
+		equal(s.ActionCode, other.ActionCode) &&
+		equal(s.RatingFrom, other.RatingFrom) &&
+		equal(s.RatingFromScore, other.RatingFromScore) &&
+		equal(s.RatingTo, other.RatingTo) &&
+		equal(s.RatingToScore, other.RatingToScore) &&
+		equal(s.TargetFrom, other.TargetFrom) &&
+		equal(s.TargetTo, other.TargetTo) &&
+		equalTime(s.RecordTime, other.RecordTime) &&
+		s.VocabUnknown == other.VocabUnknown
+}
+
+func equal[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
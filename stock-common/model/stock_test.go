@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSameContent(t *testing.T) {
+	ticker, brokerage, action := "AAPL", "Goldman Sachs", "target raised by"
+	targetFrom, targetTo, score := 200.0, 220.0, 4
+	recordTime := time.Date(2025, 7, 17, 0, 30, 7, 155596000, time.UTC)
+	base := Stock{
+		Code:          StockCode(ticker, brokerage, recordTime, action),
+		Ticker:        &ticker,
+		Brokerage:     &brokerage,
+		Action:        &action,
+		TargetFrom:    &targetFrom,
+		TargetTo:      &targetTo,
+		RatingToScore: &score,
+		RecordTime:    &recordTime,
+	}
+
+	otherTicker, otherTarget, otherScore := "MSFT", 230.0, 3
+	otherTime := recordTime.Add(time.Microsecond)
+	sameTimeOtherZone := recordTime.In(time.FixedZone("", -5*60*60))
+	now, runID, name := time.Now(), uuid.New(), "Goldman Sachs Group"
+
+	tests := []struct {
+		name   string
+		change func(*Stock)
+		want   bool
+	}{
+		{"igual", func(*Stock) {}, true},
+		{"record_time en otra zona", func(s *Stock) { s.RecordTime = &sameTimeOtherZone }, true},
+		{"marcas de auditoría", func(s *Stock) { s.CreatedAt, s.UpdatedAt, s.RunID = &now, &now, &runID }, true},
+		{"nombre canónico y calculados", func(s *Stock) { s.BrokerageName, s.Direction = &name, "upgrade" }, true},
+		{"ticker distinto", func(s *Stock) { s.Ticker = &otherTicker }, false},
+		{"ticker NULL", func(s *Stock) { s.Ticker = nil }, false},
+		{"target_to distinto", func(s *Stock) { s.TargetTo = &otherTarget }, false},
+		{"score distinto", func(s *Stock) { s.RatingToScore = &otherScore }, false},
+		{"brokerage_id asignado", func(s *Stock) { s.BrokerageID = &runID }, false},
+		{"record_time distinto", func(s *Stock) { s.RecordTime = &otherTime }, false},
+		{"vocab_unknown", func(s *Stock) { s.VocabUnknown = true }, false},
+	}
+	for _, test := range tests {
+		other := base
+		test.change(&other)
+		if got := base.SameContent(other); got != test.want {
+			t.Errorf("%s: SameContent = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -1,83 +1,60 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"stock/getter/pkg/archive"
+	"stock/getter/pkg/daemon"
 	"stock/getter/pkg/engine"
+	"stock/getter/pkg/ingest"
+	"stock/getter/pkg/source"
+	"stock/getter/pkg/upstream"
 
 	"github.com/joho/godotenv"
 	"github.com/urfave/cli"
 )
 
-/*
-*
-* Get stock list from api
-
-Query Params:
-- next_page: next page token
-
-Headers:
-- Authorization: Bearer <token>
-- Content-Type: application/json
-
-Response Item:
-
-	{
-		"ticker": "ETR",
-		"target_from": "$85.00",
-		"target_to": "$88.00",
-		"company": "Entergy",
-		"action": "target raised by",
-		"brokerage": "KeyCorp",
-		"rating_from": "Overweight",
-		"rating_to": "Overweight",
-		"time": "2025-07-17T00:30:07.155596923Z"
+// describeFetchError agrega contexto a los errores de upstream que requieren acción del usuario
+func describeFetchError(err error) error {
+	if err == nil {
+		return nil
 	}
-
-* @param nextPage: next page token
-* @return StockResponse
-*/
-func getStock(nextPage string) (engine.StockResponse, error) {
-
-	url := os.Getenv("STOCKS_URL")
-	if nextPage != "" {
-		url = fmt.Sprintf("%s?next_page=%s", url, nextPage)
+	var statusErr *upstream.StatusError
+	if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden) {
+		return fmt.Errorf("la API rechazó el token, revisa API_TOKEN: %w", err)
 	}
-
-	fmt.Println("Consultando a:", url)
-	request, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		log.Fatal(err)
+	var decodeErr *upstream.DecodeError
+	if errors.As(err, &decodeErr) {
+		fmt.Println(decodeErr.Body)
 	}
-	request.Header.Set("Authorization", "Bearer "+os.Getenv("API_TOKEN"))
-	request.Header.Set("Content-Type", "application/json")
-
-	response, err := http.DefaultClient.Do(request)
+	return err
+}
 
+// track registra la ejecución en ingest_runs y suma sus estadísticas al resumen final
+func track(ctx context.Context, command string, stats *ingest.Stats, job func(run *ingest.Run) error) error {
+	run, err := ingest.Start(ctx, command)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	defer response.Body.Close()
+	fmt.Println("Ejecución:", run.ID)
 
-	body, err := io.ReadAll(response.Body)
-	if err != nil {
-		log.Fatal(err)
+	err = describeFetchError(job(run))
+	run.Runs = 1
+	stats.Merge(run.Stats)
+	if finishErr := run.Finish(err); finishErr != nil {
+		log.Printf("error cerrando la ejecución %s: %v", run.ID, finishErr)
 	}
-
-	var stockResponse engine.StockResponse
-	err = json.Unmarshal(body, &stockResponse)
-	if err != nil {
-		fmt.Println(string(body))
-		log.Fatal(err)
-	}
-
-	return stockResponse, nil
+	return err
 }
 
 func main() {
@@ -89,8 +66,7 @@ func main() {
 	app.Usage = "Obtiene las acciones de la API"
 
 	startTime := time.Now()
-	totalItems := 0
-	counter := 0
+	var stats ingest.Stats
 
 	app.Commands = []cli.Command{
 
@@ -102,42 +78,291 @@ func main() {
 				cli.StringFlag{
 					Name:  "next_page",
 					Value: "",
-					Usage: "Token de la siguiente página (opcional)",
+					Usage: "Token de la siguiente página (opcional, tiene prioridad sobre el checkpoint)",
+				},
+				cli.BoolFlag{
+					Name:  "restart",
+					Usage: "Ignora el checkpoint guardado y comienza desde la primera página",
 				},
 			},
 			Action: func(c *cli.Context) error {
 
 				var nextPage string = c.String("next_page")
-				fmt.Println("nextPage", nextPage)
-				for {
-					stockResponse, err := getStock(nextPage)
-					counter++
+				if nextPage == "" && !c.Bool("restart") {
+					checkpoint, err := engine.LoadCheckpoint()
 					if err != nil {
-						log.Fatal(err)
+						log.Fatalf("error leyendo checkpoint: %v", err)
+					}
+					if checkpoint != "" {
+						fmt.Println("Reanudando desde checkpoint:", checkpoint)
+						nextPage = checkpoint
 					}
-					// fmt.Println(stockResponse)
-					nextPage = stockResponse.NextPage
-					totalItems += len(stockResponse.Items)
-					err = engine.InsertStocks(stockResponse)
+				}
+				fmt.Println("nextPage", nextPage)
+				client := upstream.NewClient(upstream.ConfigFromEnv())
+				return track(context.Background(), "download", &stats, func(run *ingest.Run) error {
+					return ingest.Download(context.Background(), client, nextPage, run)
+				})
+			},
+		},
+		{
+			Name:    "sync",
+			Aliases: []string{"s"},
+			Usage:   "Obtiene solo las acciones más recientes que el último record_time guardado",
+			Action: func(c *cli.Context) error {
+				client := upstream.NewClient(upstream.ConfigFromEnv())
+				return track(context.Background(), "sync", &stats, func(run *ingest.Run) error {
+					return ingest.Sync(context.Background(), client, run)
+				})
+			},
+		},
+		{
+			Name:  "import",
+			Usage: "Importa acciones desde un archivo local JSON, NDJSON o CSV",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "file",
+					Usage: "Ruta del archivo a importar",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "json, ndjson o csv (por defecto se deduce de la extensión)",
+				},
+				cli.IntFlag{
+					Name:  "batch",
+					Value: 500,
+					Usage: "Items por lote (cada lote se guarda en su propia transacción)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.String("file") == "" {
+					return errors.New("se requiere --file")
+				}
+				src, err := source.Open(c.String("file"), c.String("format"), c.Int("batch"))
+				if err != nil {
+					return err
+				}
+				return track(context.Background(), "import", &stats, func(run *ingest.Run) error {
+					return ingest.Import(context.Background(), src, run)
+				})
+			},
+		},
+		{
+			Name:  "replay",
+			Usage: "Vuelve a parsear e insertar las páginas crudas archivadas sin consultar la API",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "dir",
+					Usage: "Carpeta del archivo de páginas (por defecto RAW_ARCHIVE_DIR o raw_pages)",
+				},
+				cli.StringFlag{
+					Name:  "since",
+					Usage: "Solo reprocesa páginas consultadas desde esta fecha (RFC3339, ej. 2025-07-01T00:00:00Z)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				pages := archive.FromEnv()
+				if c.String("dir") != "" {
+					pages = archive.New(c.String("dir"))
+				}
+				if pages == nil {
+					return errors.New("RAW_ARCHIVE_DIR=off, indica la carpeta con --dir")
+				}
+
+				var since time.Time
+				if c.String("since") != "" {
+					parsed, err := time.Parse(time.RFC3339, c.String("since"))
 					if err != nil {
-						log.Fatal(err)
+						return fmt.Errorf("--since inválido: %w", err)
 					}
-					break
-					// if nextPage == "" {
-					// 	break
-					// }
+					since = parsed
+				}
+
+				src, err := source.NewArchive(pages, since)
+				if err != nil {
+					return err
+				}
+				fmt.Println("Páginas a reprocesar:", src.Len())
+				return track(context.Background(), "replay", &stats, func(run *ingest.Run) error {
+					return ingest.Import(context.Background(), src, run)
+				})
+			},
+		},
+		{
+			Name:  "brokerages",
+			Usage: "Administra el registro de brokerages canónicos y sus alias",
+			Subcommands: []cli.Command{
+				{
+					Name:  "list",
+					Usage: "Lista los brokerages con sus alias y cantidad de calificaciones",
+					Action: func(c *cli.Context) error {
+						brokerages, err := engine.ListBrokerages(context.Background())
+						if err != nil {
+							return err
+						}
+						for _, brokerage := range brokerages {
+							fmt.Printf("%s\t%s\t%d calificaciones\talias: %s\n", brokerage.ID, brokerage.Name, brokerage.Stocks, strings.Join(brokerage.Aliases, ", "))
+						}
+						return nil
+					},
+				},
+				{
+					Name:      "alias",
+					Usage:     "Registra un alias para un brokerage existente",
+					ArgsUsage: "<brokerage> <alias>",
+					Action: func(c *cli.Context) error {
+						if c.NArg() != 2 {
+							return errors.New("uso: brokerages alias <brokerage> <alias>")
+						}
+						return engine.AddBrokerageAlias(context.Background(), c.Args().Get(0), c.Args().Get(1))
+					},
+				},
+				{
+					Name:      "merge",
+					Usage:     "Une un brokerage dentro de otro, moviendo sus alias y calificaciones",
+					ArgsUsage: "<desde> <hacia>",
+					Action: func(c *cli.Context) error {
+						if c.NArg() != 2 {
+							return errors.New("uso: brokerages merge <desde> <hacia>")
+						}
+						return engine.MergeBrokerages(context.Background(), c.Args().Get(0), c.Args().Get(1))
+					},
+				},
+			},
+		},
+		{
+			Name:  "migrate",
+			Usage: "Administra las migraciones del esquema de base de datos",
+			Subcommands: []cli.Command{
+				{
+					Name:  "up",
+					Usage: "Aplica las migraciones pendientes",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "to",
+							Usage: "Versión hasta la que se migra (por defecto la última)",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						applied, err := engine.MigrateUp(context.Background(), c.Int("to"))
+						for _, migration := range applied {
+							fmt.Printf("Aplicada %04d_%s\n", migration.Version, migration.Name)
+						}
+						if err == nil && len(applied) == 0 {
+							fmt.Println("No hay migraciones pendientes")
+						}
+						return err
+					},
+				},
+				{
+					Name:  "down",
+					Usage: "Revierte las últimas migraciones aplicadas",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "steps",
+							Value: 1,
+							Usage: "Cantidad de migraciones a revertir",
+						},
+					},
+					Action: func(c *cli.Context) error {
+						reverted, err := engine.MigrateDown(context.Background(), c.Int("steps"))
+						for _, migration := range reverted {
+							fmt.Printf("Revertida %04d_%s\n", migration.Version, migration.Name)
+						}
+						return err
+					},
+				},
+				{
+					Name:  "status",
+					Usage: "Muestra qué migraciones están aplicadas",
+					Action: func(c *cli.Context) error {
+						statuses, err := engine.MigrationStatus(context.Background())
+						if err != nil {
+							return err
+						}
+						for _, status := range statuses {
+							appliedAt := "pendiente"
+							if status.AppliedAt != nil {
+								appliedAt = status.AppliedAt.Format(time.RFC3339)
+							}
+							fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, appliedAt)
+						}
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "daemon",
+			Usage: "Ejecuta sync periódicamente y expone su estado por HTTP",
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:  "interval",
+					Value: time.Hour,
+					Usage: "Intervalo entre ejecuciones",
+				},
+				cli.StringFlag{
+					Name:  "cron",
+					Usage: "Expresión cron estándar, ej. \"0 6 * * 1-5\" (tiene prioridad sobre --interval)",
+				},
+				cli.StringFlag{
+					Name:   "addr",
+					Value:  ":8090",
+					Usage:  "Dirección del endpoint de estado",
+					EnvVar: "DAEMON_ADDR",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				schedule, err := daemon.ParseSchedule(c.String("cron"), c.Duration("interval"))
+				if err != nil {
+					return err
+				}
+
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+
+				client := upstream.NewClient(upstream.ConfigFromEnv())
+				d := daemon.New(func(ctx context.Context) (int, error) {
+					var runStats ingest.Stats
+					err := track(ctx, "daemon", &runStats, func(run *ingest.Run) error {
+						return ingest.Sync(ctx, client, run)
+					})
+					stats.Merge(runStats)
+					return runStats.Items, err
+				}, schedule)
+
+				listener, err := net.Listen("tcp", c.String("addr"))
+				if err != nil {
+					return fmt.Errorf("no se pudo abrir el endpoint de estado en %s: %w", c.String("addr"), err)
 				}
-				return nil
+
+				// Si el endpoint de estado falla se detiene también el daemon
+				serveErr := make(chan error, 1)
+				go func() {
+					err := d.Serve(ctx, listener)
+					if err != nil {
+						log.Printf("error en el endpoint de estado: %v", err)
+						stop()
+					}
+					serveErr <- err
+				}()
+
+				d.Start(ctx)
+				log.Println("Deteniendo daemon")
+				stop()
+				return <-serveErr
 			},
 		},
 	}
 
 	err := app.Run(os.Args)
-	if err != nil {
-		panic(err)
+
+	// Solo los comandos de ingesta tienen resumen
+	if stats.Runs > 0 {
+		stats.Print(time.Since(startTime))
 	}
 
-	fmt.Println("Total de llamadas ejecutadas:", counter)
-	fmt.Println("Total de items insertados:", totalItems)
-	fmt.Println("Tiempo total de ejecución:", time.Since(startTime))
+	if err != nil {
+		log.Fatal(err)
+	}
 }
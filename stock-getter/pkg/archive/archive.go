@@ -0,0 +1,156 @@
+package archive
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDir = "raw_pages"
+	// Formato de fecha usado en el nombre de archivo; ordena lexicográficamente igual que cronológicamente
+	timeLayout = "20060102T150405.000000000Z"
+	extension  = ".json.gz"
+	firstPage  = "first"
+)
+
+// Archive guarda en disco, comprimido, el cuerpo crudo de cada página recibida de la API.
+// Cada archivo se nombra <fecha de consulta>_<token de la página>.json.gz
+type Archive struct {
+	dir string
+}
+
+// Entry describe una página archivada
+type Entry struct {
+	Path      string
+	PageToken string
+	FetchedAt time.Time
+}
+
+func New(dir string) *Archive {
+	return &Archive{dir: dir}
+}
+
+// FromEnv usa RAW_ARCHIVE_DIR (por defecto raw_pages). Con RAW_ARCHIVE_DIR=off devuelve nil y no se archiva.
+func FromEnv() *Archive {
+	dir := os.Getenv("RAW_ARCHIVE_DIR")
+	if strings.EqualFold(dir, "off") {
+		return nil
+	}
+	if dir == "" {
+		dir = defaultDir
+	}
+	return New(dir)
+}
+
+// Save comprime y guarda el cuerpo de la página consultada con pageToken ("" para la primera)
+func (a *Archive) Save(pageToken string, fetchedAt time.Time, body []byte) error {
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(a.dir, fileName(pageToken, fetchedAt))
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(file)
+	writer.Name = pageToken
+	writer.ModTime = fetchedAt
+	if _, err := writer.Write(body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// List devuelve las páginas archivadas desde since (inclusive), ordenadas por fecha de consulta
+func (a *Archive) List(since time.Time) ([]Entry, error) {
+	files, err := os.ReadDir(a.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), extension) {
+			continue
+		}
+		entry, err := parseFileName(file.Name())
+		if err != nil {
+			return nil, err
+		}
+		if entry.FetchedAt.Before(since) {
+			continue
+		}
+		entry.Path = filepath.Join(a.dir, file.Name())
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FetchedAt.Before(entries[j].FetchedAt)
+	})
+	return entries, nil
+}
+
+// Read descomprime el cuerpo de una página archivada
+func Read(entry Entry) ([]byte, error) {
+	file, err := os.Open(entry.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", entry.Path, err)
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// El token se codifica en base64 URL para que cualquier carácter sea válido en el nombre de archivo
+func fileName(pageToken string, fetchedAt time.Time) string {
+	token := firstPage
+	if pageToken != "" {
+		token = base64.RawURLEncoding.EncodeToString([]byte(pageToken))
+	}
+	return fetchedAt.UTC().Format(timeLayout) + "_" + token + extension
+}
+
+func parseFileName(name string) (Entry, error) {
+	base := strings.TrimSuffix(name, extension)
+	timePart, tokenPart, ok := strings.Cut(base, "_")
+	if !ok {
+		return Entry{}, fmt.Errorf("nombre de archivo inválido en el archivo de páginas: %s", name)
+	}
+	fetchedAt, err := time.Parse(timeLayout, timePart)
+	if err != nil {
+		return Entry{}, fmt.Errorf("nombre de archivo inválido en el archivo de páginas: %s: %w", name, err)
+	}
+
+	var token string
+	if tokenPart != firstPage {
+		decoded, err := base64.RawURLEncoding.DecodeString(tokenPart)
+		if err != nil {
+			return Entry{}, fmt.Errorf("nombre de archivo inválido en el archivo de páginas: %s: %w", name, err)
+		}
+		token = string(decoded)
+	}
+	return Entry{PageToken: token, FetchedAt: fetchedAt}, nil
+}
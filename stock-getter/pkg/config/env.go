@@ -0,0 +1,55 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Int lee un entero positivo de la variable de entorno o devuelve el valor por defecto
+func Int(name string, defaultValue int) int {
+	return IntAtLeast(name, defaultValue, 1)
+}
+
+// IntAtLeast lee un entero mayor o igual que minValue de la variable de entorno o devuelve el valor por defecto
+func IntAtLeast(name string, defaultValue int, minValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < minValue {
+		log.Printf("valor inválido para %s (%q), usando %d", name, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// Duration lee una duración (ej. "30s", "2m") de la variable de entorno o devuelve el valor por defecto
+func Duration(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("valor inválido para %s (%q), usando %s", name, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// Float lee un número decimal de la variable de entorno o devuelve el valor por defecto
+func Float(name string, defaultValue float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed <= 0 {
+		log.Printf("valor inválido para %s (%q), usando %g", name, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
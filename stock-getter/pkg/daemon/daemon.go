@@ -0,0 +1,151 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job es el trabajo que ejecuta el daemon en cada disparo; devuelve la cantidad de items procesados
+type Job func(ctx context.Context) (int, error)
+
+// Status es lo que expone el endpoint HTTP de estado
+type Status struct {
+	Running      bool       `json:"running"`
+	Runs         int        `json:"runs"`
+	LastRunStart *time.Time `json:"last_run_start"`
+	LastRunEnd   *time.Time `json:"last_run_end"`
+	LastItems    int        `json:"last_items"`
+	LastError    *string    `json:"last_error"`
+	NextRun      *time.Time `json:"next_run"`
+}
+
+type Daemon struct {
+	job      Job
+	schedule cron.Schedule
+
+	// runMu garantiza que solo exista una ejecución a la vez
+	runMu sync.Mutex
+
+	mu     sync.Mutex
+	status Status
+}
+
+// ParseSchedule arma la planificación a partir de una expresión cron estándar (5 campos)
+// o, si no se indica, de un intervalo fijo
+func ParseSchedule(expression string, interval time.Duration) (cron.Schedule, error) {
+	if expression != "" {
+		schedule, err := cron.ParseStandard(expression)
+		if err != nil {
+			return nil, fmt.Errorf("expresión cron inválida %q: %w", expression, err)
+		}
+		return schedule, nil
+	}
+	if interval < time.Second {
+		return nil, fmt.Errorf("el intervalo debe ser de al menos 1s, se recibió %s", interval)
+	}
+	return cron.Every(interval), nil
+}
+
+func New(job Job, schedule cron.Schedule) *Daemon {
+	return &Daemon{job: job, schedule: schedule}
+}
+
+// Start ejecuta el trabajo inmediatamente y luego según la planificación hasta que se cancele el contexto.
+// Si una ejecución se extiende más allá del siguiente disparo, ese disparo se omite.
+func (d *Daemon) Start(ctx context.Context) {
+	d.RunOnce(ctx)
+	for {
+		next := d.schedule.Next(time.Now())
+		d.mu.Lock()
+		d.status.NextRun = &next
+		d.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			d.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce ejecuta el trabajo si no hay otra ejecución en curso; devuelve false si se omitió
+func (d *Daemon) RunOnce(ctx context.Context) bool {
+	if !d.runMu.TryLock() {
+		log.Println("Ya hay una ejecución en curso, se omite este disparo")
+		return false
+	}
+	defer d.runMu.Unlock()
+
+	start := time.Now()
+	d.mu.Lock()
+	d.status.Running = true
+	d.status.LastRunStart = &start
+	d.mu.Unlock()
+
+	log.Println("Iniciando ejecución programada")
+	items, err := d.job(ctx)
+	end := time.Now()
+	if err != nil {
+		log.Printf("Ejecución terminada con error: %v", err)
+	} else {
+		log.Printf("Ejecución terminada: %d items en %s", items, end.Sub(start))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status.Running = false
+	d.status.Runs++
+	d.status.LastRunEnd = &end
+	d.status.LastItems = items
+	d.status.LastError = nil
+	if err != nil {
+		message := err.Error()
+		d.status.LastError = &message
+	}
+	return true
+}
+
+func (d *Daemon) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// ServeHTTP responde el estado actual del daemon en JSON
+func (d *Daemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d.Status())
+}
+
+// Serve atiende el endpoint de estado en listener hasta que se cancele el contexto.
+// El listener se abre antes de arrancar el scheduler para fallar de inmediato si la dirección está ocupada.
+func (d *Daemon) Serve(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/status", d)
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("[Estado del daemon en %s/status]", listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
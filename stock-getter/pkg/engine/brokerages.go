@@ -0,0 +1,219 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"stock/common/model"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Brokerage es una firma canónica del registro con sus alias
+type Brokerage struct {
+	ID      uuid.UUID
+	Name    string
+	Aliases []string
+	Stocks  int
+}
+
+// brokerageAlias normaliza el nombre tal como llega de la API para usarlo como alias:
+// minúsculas, espacios colapsados y sin puntuación final ("KeyCorp." y "keycorp" son el mismo alias)
+func brokerageAlias(name string) string {
+	alias := strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	return strings.TrimRight(alias, ".,")
+}
+
+// resolveBrokerages asigna a cada fila el brokerage canónico según su alias.
+// Los nombres que aún no están en el registro se dan de alta como una firma nueva.
+func resolveBrokerages(ctx context.Context, tx pgx.Tx, rows map[uuid.UUID]model.Stock) error {
+	aliases := make(map[string]uuid.UUID)
+	for code, row := range rows {
+		if row.Brokerage == nil {
+			continue
+		}
+		alias := brokerageAlias(*row.Brokerage)
+		if alias == "" {
+			continue
+		}
+		id, ok := aliases[alias]
+		if !ok {
+			var err error
+			id, err = ensureBrokerage(ctx, tx, *row.Brokerage, alias)
+			if err != nil {
+				return err
+			}
+			aliases[alias] = id
+		}
+		row.BrokerageID = &id
+		rows[code] = row
+	}
+	return nil
+}
+
+func ensureBrokerage(ctx context.Context, tx pgx.Tx, name string, alias string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, "SELECT brokerage_id FROM brokerage_aliases WHERE alias = $1", alias).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("error resolving brokerage %q: %w", name, err)
+	}
+
+	name = strings.Join(strings.Fields(name), " ")
+	err = tx.QueryRow(ctx, `INSERT INTO brokerages (id, name, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET name = excluded.name
+		RETURNING id`, uuid.New(), name, time.Now()).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error registering brokerage %q: %w", name, err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO brokerage_aliases (alias, brokerage_id) VALUES ($1, $2)", alias, id); err != nil {
+		return uuid.Nil, fmt.Errorf("error registering alias %q: %w", alias, err)
+	}
+	return id, nil
+}
+
+// withBrokerageTx abre una conexión, verifica el esquema y ejecuta fn dentro de una transacción
+func withBrokerageTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	db, err := connectToDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close(ctx)
+
+	if err := checkSchema(ctx, db); err != nil {
+		return err
+	}
+	return pgx.BeginFunc(ctx, db, fn)
+}
+
+func findBrokerage(ctx context.Context, tx pgx.Tx, name string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `SELECT b.id FROM brokerages b
+		LEFT JOIN brokerage_aliases a ON a.brokerage_id = b.id
+		WHERE b.name = $1 OR a.alias = $2
+		LIMIT 1`, name, brokerageAlias(name)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("no existe el brokerage %q", name)
+	}
+	return id, err
+}
+
+// ListBrokerages devuelve el registro completo con sus alias y la cantidad de calificaciones de cada firma
+func ListBrokerages(ctx context.Context) ([]Brokerage, error) {
+	var brokerages []Brokerage
+	err := withBrokerageTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `SELECT b.id, b.name,
+				COALESCE((SELECT array_agg(alias ORDER BY alias) FROM brokerage_aliases WHERE brokerage_id = b.id), ARRAY[]::TEXT[]),
+				(SELECT count(*) FROM stocks WHERE brokerage_id = b.id)
+			FROM brokerages b
+			ORDER BY b.name`)
+		if err != nil {
+			return err
+		}
+		brokerages, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Brokerage, error) {
+			var brokerage Brokerage
+			err := row.Scan(&brokerage.ID, &brokerage.Name, &brokerage.Aliases, &brokerage.Stocks)
+			return brokerage, err
+		})
+		return err
+	})
+	return brokerages, err
+}
+
+// AddBrokerageAlias hace que alias apunte al brokerage canónico name y reasigna
+// las calificaciones que llegaron con ese nombre
+func AddBrokerageAlias(ctx context.Context, name string, alias string) error {
+	normalized := brokerageAlias(alias)
+	if normalized == "" {
+		return errors.New("el alias no puede estar vacío")
+	}
+
+	return withBrokerageTx(ctx, func(tx pgx.Tx) error {
+		id, err := findBrokerage(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		var previousID uuid.UUID
+		err = tx.QueryRow(ctx, "SELECT brokerage_id FROM brokerage_aliases WHERE alias = $1", normalized).Scan(&previousID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `INSERT INTO brokerage_aliases (alias, brokerage_id) VALUES ($1, $2)
+			ON CONFLICT (alias) DO UPDATE SET brokerage_id = excluded.brokerage_id`, normalized, id)
+		if err != nil {
+			return err
+		}
+		if err := reassignStocks(ctx, tx, normalized, id); err != nil {
+			return err
+		}
+
+		// La firma que tenía el alias se elimina si quedó sin alias ni calificaciones
+		if previousID != uuid.Nil && previousID != id {
+			_, err = tx.Exec(ctx, `DELETE FROM brokerages WHERE id = $1
+				AND NOT EXISTS (SELECT 1 FROM brokerage_aliases WHERE brokerage_id = $1)
+				AND NOT EXISTS (SELECT 1 FROM stocks WHERE brokerage_id = $1)`, previousID)
+		}
+		return err
+	})
+}
+
+// reassignStocks apunta a brokerageID todas las calificaciones cuyo brokerage normalizado es alias
+func reassignStocks(ctx context.Context, tx pgx.Tx, alias string, brokerageID uuid.UUID) error {
+	rows, err := tx.Query(ctx, "SELECT DISTINCT brokerage FROM stocks WHERE brokerage IS NOT NULL AND (brokerage_id IS NULL OR brokerage_id <> $1)", brokerageID)
+	if err != nil {
+		return err
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return err
+	}
+
+	var matching []string
+	for _, name := range names {
+		if brokerageAlias(name) == alias {
+			matching = append(matching, name)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	_, err = tx.Exec(ctx, "UPDATE stocks SET brokerage_id = $1 WHERE brokerage = ANY($2)", brokerageID, matching)
+	return err
+}
+
+// MergeBrokerages une from dentro de into: mueve sus alias y calificaciones y elimina from
+func MergeBrokerages(ctx context.Context, from string, into string) error {
+	return withBrokerageTx(ctx, func(tx pgx.Tx) error {
+		fromID, err := findBrokerage(ctx, tx, from)
+		if err != nil {
+			return err
+		}
+		intoID, err := findBrokerage(ctx, tx, into)
+		if err != nil {
+			return err
+		}
+		if fromID == intoID {
+			return fmt.Errorf("%q y %q ya son el mismo brokerage", from, into)
+		}
+
+		statements := []string{
+			"UPDATE brokerage_aliases SET brokerage_id = $2 WHERE brokerage_id = $1",
+			"UPDATE stocks SET brokerage_id = $2 WHERE brokerage_id = $1",
+			"DELETE FROM brokerages WHERE id = $1",
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement, fromID, intoID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -4,51 +4,209 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"strconv"
-	"strings"
 	"time"
 
+	"stock/common/model"
+	"stock/getter/pkg/config"
+	"stock/getter/pkg/vocab"
+
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func InsertStocks(stockResponse StockResponse) error {
+// UpsertResult resume lo que pasó con cada item de una página
+type UpsertResult struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+	Rejected  int
+	// Unknown cuenta las filas guardadas con action o rating fuera del vocabulario
+	Unknown int
+}
+
+func (r *UpsertResult) Add(other UpsertResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Unchanged += other.Unchanged
+	r.Rejected += other.Rejected
+	r.Unknown += other.Unknown
+}
+
+// parseStockItem valida el item y completa los valores canónicos del vocabulario
+func parseStockItem(item StockItem, vocabulary *vocab.Vocabulary) (model.Stock, error) {
+	stock, err := model.FromItem(item)
+	if err != nil {
+		return model.Stock{}, err
+	}
+	normalize(&stock, vocabulary)
+	return stock, nil
+}
+
+const upsertStockQuery = `INSERT INTO stocks (code, ticker, target_from, target_to, company, action, brokerage, rating_from, rating_to, record_time, created_at, updated_at, run_id, action_code, rating_from_score, rating_to_score, vocab_unknown, brokerage_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11, $12, $13, $14, $15, $16, $17)
+	ON CONFLICT (code) DO UPDATE SET
+		ticker = excluded.ticker,
+		target_from = excluded.target_from,
+		target_to = excluded.target_to,
+		company = excluded.company,
+		action = excluded.action,
+		brokerage = excluded.brokerage,
+		rating_from = excluded.rating_from,
+		rating_to = excluded.rating_to,
+		record_time = excluded.record_time,
+		updated_at = excluded.updated_at,
+		run_id = excluded.run_id,
+		action_code = excluded.action_code,
+		rating_from_score = excluded.rating_from_score,
+		rating_to_score = excluded.rating_to_score,
+		vocab_unknown = excluded.vocab_unknown,
+		brokerage_id = excluded.brokerage_id`
+
+// findStocks trae las filas ya guardadas para los codes indicados
+func findStocks(ctx context.Context, db pgx.Tx, codes []uuid.UUID) (map[uuid.UUID]model.Stock, error) {
+	existing := make(map[uuid.UUID]model.Stock)
+	if len(codes) == 0 {
+		return existing, nil
+	}
+
+	rows, err := db.Query(ctx, "SELECT "+model.Columns+" FROM stocks WHERE code = ANY($1)", codes)
+	if err != nil {
+		return nil, fmt.Errorf("error querying existing stocks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stock model.Stock
+		if err := rows.Scan(stock.Fields()...); err != nil {
+			return nil, fmt.Errorf("error scanning existing stock: %w", err)
+		}
+		existing[stock.Code] = stock
+	}
+
+	return existing, rows.Err()
+}
+
+// sendBatch envía los upserts encolados y verifica el resultado de cada uno
+func sendBatch(ctx context.Context, tx pgx.Tx, batch *pgx.Batch) error {
+	results := tx.SendBatch(ctx, batch)
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := results.Exec(); err != nil {
+			results.Close()
+			return fmt.Errorf("error upserting stock: %w", err)
+		}
+	}
+	return results.Close()
+}
+
+// InsertStocks guarda una página completa dentro de una sola transacción:
+// o quedan todas las filas válidas o no queda ninguna. Los items que no pasan
+// la validación se envían al archivo de rechazados (REJECTS_FILE) y no detienen la carga.
+// Las filas insertadas o actualizadas quedan marcadas con runID (ver ingest_runs).
+// db es el pool de la ejecución (ver Connect), compartido por todas sus páginas.
+//
+// Variables de entorno:
+//   - INSERT_BATCH_SIZE: upserts enviados por viaje a la base (por defecto 500)
+//   - INSERT_TIMEOUT: tiempo máximo para guardar la página (por defecto 60s)
+func InsertStocks(db *pgxpool.Pool, runID uuid.UUID, items []StockItem) (UpsertResult, error) {
+
+	var result UpsertResult
+	batchSize := config.Int("INSERT_BATCH_SIZE", 500)
 
-	db, err := connectToDB()
+	vocabulary, err := vocab.Current()
 	if err != nil {
-		log.Fatalf("cannot connect: %v", err)
+		return UpsertResult{}, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.Duration("INSERT_TIMEOUT", 60*time.Second))
 	defer cancel()
 
-	if err := db.Ping(ctx); err != nil {
-		log.Fatalf("cannot connect: %v", err)
+	// Si la misma calificación viene repetida en la página se queda la última
+	rows := make(map[uuid.UUID]model.Stock)
+	codes := make([]uuid.UUID, 0, len(items))
+	var rejects []RejectedItem
+	for _, stock := range items {
+		row, err := parseStockItem(stock, vocabulary)
+		if err != nil {
+			log.Printf("item rechazado (%s): %v", stock.Ticker, err)
+			rejects = append(rejects, RejectedItem{RejectedAt: time.Now(), Reason: err.Error(), Item: stock})
+			continue
+		}
+		if _, ok := rows[row.Code]; !ok {
+			codes = append(codes, row.Code)
+		}
+		rows[row.Code] = row
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := resolveBrokerages(ctx, tx, rows); err != nil {
+		return UpsertResult{}, err
+	}
+
+	existing, err := findStocks(ctx, tx, codes)
+	if err != nil {
+		return UpsertResult{}, err
 	}
 
 	fmt.Println("Iniciando inserción de stocks")
-	for _, stock := range stockResponse.Items {
-		code := uuid.New().String()
-		targetFromCleaned := strings.ReplaceAll(stock.TargetFrom, "$", "")
-		targetFromCleaned = strings.ReplaceAll(targetFromCleaned, ",", "")
-		targetFrom, err1 := strconv.ParseFloat(targetFromCleaned, 64)
-		if err1 != nil {
-			log.Fatalf("error parsing target from: %v", err1)
-		}
-		targetToCleaned := strings.ReplaceAll(stock.TargetTo, "$", "")
-		targetToCleaned = strings.ReplaceAll(targetToCleaned, ",", "")
-		targetTo, err2 := strconv.ParseFloat(targetToCleaned, 64)
-		if err2 != nil {
-			log.Fatalf("error parsing target to: %v", err2)
-		}
-		createdAt := time.Now()
-		updatedAt := time.Now()
-		_, err := db.Exec(ctx, "INSERT INTO stocks (code, ticker, target_from, target_to, company, action, brokerage, rating_from, rating_to, record_time, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)", code, stock.Ticker, targetFrom, targetTo, stock.Company, stock.Action, stock.Brokerage, stock.RatingFrom, stock.RatingTo, stock.Time, createdAt, updatedAt)
-		if err != nil {
-			log.Fatalf("error inserting stock: %v", err)
+	now := time.Now()
+	batch := &pgx.Batch{}
+	for _, code := range codes {
+		row := rows[code]
+		current, found := existing[code]
+		if found && current.SameContent(row) {
+			result.Unchanged++
+			continue
+		}
+		if found {
+			result.Updated++
+		} else {
+			result.Inserted++
 		}
+		if row.VocabUnknown {
+			result.Unknown++
+		}
+
+		batch.Queue(upsertStockQuery, row.Code, row.Ticker, row.TargetFrom, row.TargetTo, row.Company, row.Action, row.Brokerage, row.RatingFrom, row.RatingTo, row.RecordTime, now, runID, row.ActionCode, row.RatingFromScore, row.RatingToScore, row.VocabUnknown, row.BrokerageID)
+		if batch.Len() >= batchSize {
+			if err := sendBatch(ctx, tx, batch); err != nil {
+				return UpsertResult{}, err
+			}
+			batch = &pgx.Batch{}
+		}
+	}
+	if batch.Len() > 0 {
+		if err := sendBatch(ctx, tx, batch); err != nil {
+			return UpsertResult{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return UpsertResult{}, fmt.Errorf("error committing stocks: %w", err)
+	}
+
+	// Los rechazados se escriben después del commit para no duplicarlos si la página se reintenta
+	if err := writeRejects(rejects); err != nil {
+		return result, fmt.Errorf("error writing rejected items: %w", err)
 	}
+	result.Rejected = len(rejects)
 
-	defer db.Close(ctx)
+	return result, nil
+}
 
-	return nil
+// LatestRecordTime devuelve el record_time más reciente guardado; found es false si la tabla está vacía
+func LatestRecordTime(ctx context.Context, db *pgxpool.Pool) (latest time.Time, found bool, err error) {
+	var recordTime *time.Time
+	if err := db.QueryRow(ctx, "SELECT max(record_time) FROM stocks").Scan(&recordTime); err != nil {
+		return time.Time{}, false, err
+	}
+	if recordTime == nil {
+		return time.Time{}, false, nil
+	}
+	return *recordTime, true, nil
 }
@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+const defaultCheckpointFile = ".download_checkpoint"
+
+func checkpointPath() string {
+	path := os.Getenv("CHECKPOINT_FILE")
+	if path == "" {
+		path = defaultCheckpointFile
+	}
+	return path
+}
+
+// LoadCheckpoint devuelve el último token next_page guardado o "" si no existe
+func LoadCheckpoint() (string, error) {
+	content, err := os.ReadFile(checkpointPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// SaveCheckpoint guarda el token de la siguiente página a consultar.
+// Se escribe en un archivo temporal y se renombra para no dejar el checkpoint a medias.
+func SaveCheckpoint(nextPage string) error {
+	path := checkpointPath()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(nextPage+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// ClearCheckpoint elimina el checkpoint cuando el recorrido terminó completo
+func ClearCheckpoint() error {
+	err := os.Remove(checkpointPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -3,30 +3,39 @@ package engine
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+
+	"stock/common/db"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func connectToDB() (*pgx.Conn, error) {
 
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	database := os.Getenv("DB_DATABASE")
+	conn, err := db.Connect(context.Background(), db.ConfigFromEnv())
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Conectado a CockroachDB")
 
-	url := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=require", user, password, host, port, database)
-	dsn := fmt.Sprintf(url)
+	return conn, nil
 
-	db, err := pgx.Connect(context.Background(), dsn)
+}
+
+// Connect abre el pool que usa una ejecución completa (registro en ingest_runs y todas sus páginas)
+// y verifica que la base responda y tenga las migraciones de este binario
+func Connect(ctx context.Context) (*pgxpool.Pool, error) {
+	pool, err := db.NewPool(ctx, db.ConfigFromEnv())
 	if err != nil {
-		log.Fatalf("error connecting to database: %v", err)
+		return nil, err
+	}
+	if err := checkSchema(ctx, pool); err != nil {
+		pool.Close()
+		return nil, err
 	}
 
 	fmt.Println("Conectado a CockroachDB")
 
-	return db, nil
-
+	return pool, nil
 }
@@ -1,18 +1,9 @@
 package engine
 
-type StockItem struct {
-	Ticker     string `json:"ticker"`
-	TargetFrom string `json:"target_from"`
-	TargetTo   string `json:"target_to"`
-	Company    string `json:"company"`
-	Action     string `json:"action"`
-	Brokerage  string `json:"brokerage"`
-	RatingFrom string `json:"rating_from"`
-	RatingTo   string `json:"rating_to"`
-	Time       string `json:"time"`
-}
+import "stock/common/model"
 
-type StockResponse struct {
-	Items    []StockItem `json:"items"`
-	NextPage string      `json:"next_page"`
-}
+// El formato de la API se define en el módulo compartido junto con el modelo de la tabla stocks
+type (
+	StockItem     = model.StockItem
+	StockResponse = model.StockResponse
+)
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"stock/common/model"
+	"stock/getter/pkg/vocab"
+)
+
+// Textos desconocidos ya reportados, para avisar una sola vez por ejecución
+var reportedUnknown sync.Map
+
+// normalize llena action_code y los scores de rating a partir del vocabulario.
+// Los textos vacíos no se consideran desconocidos; los que no estén en el vocabulario
+// dejan el campo canónico en NULL y marcan la fila con vocab_unknown.
+func normalize(stock *model.Stock, vocabulary *vocab.Vocabulary) {
+	stock.ActionCode = nil
+	stock.RatingFromScore = nil
+	stock.RatingToScore = nil
+	stock.VocabUnknown = false
+
+	if text := textOf(stock.Action); text != "" {
+		if action, ok := vocabulary.Action(text); ok {
+			stock.ActionCode = &action
+		} else {
+			flagUnknown(stock, "action", text)
+		}
+	}
+	if text := textOf(stock.RatingFrom); text != "" {
+		if rating, ok := vocabulary.Rating(text); ok {
+			stock.RatingFromScore = &rating
+		} else {
+			flagUnknown(stock, "rating", text)
+		}
+	}
+	if text := textOf(stock.RatingTo); text != "" {
+		if rating, ok := vocabulary.Rating(text); ok {
+			stock.RatingToScore = &rating
+		} else {
+			flagUnknown(stock, "rating", text)
+		}
+	}
+}
+
+func flagUnknown(stock *model.Stock, kind string, text string) {
+	stock.VocabUnknown = true
+	if _, reported := reportedUnknown.LoadOrStore(kind+"|"+text, true); !reported {
+		log.Printf("%s desconocido en el vocabulario: %q (agrégalo en VOCABULARY_FILE)", kind, text)
+	}
+}
+
+func textOf(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return strings.TrimSpace(*value)
+}
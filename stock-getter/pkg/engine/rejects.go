@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const defaultRejectsFile = "stocks_rejected.ndjson"
+
+// RejectedItem es una línea del archivo de rechazados: el item tal cual llegó y por qué no se guardó
+type RejectedItem struct {
+	RejectedAt time.Time `json:"rejected_at"`
+	Reason     string    `json:"reason"`
+	Item       StockItem `json:"item"`
+}
+
+func rejectsPath() string {
+	path := os.Getenv("REJECTS_FILE")
+	if path == "" {
+		path = defaultRejectsFile
+	}
+	return path
+}
+
+// writeRejects agrega los items rechazados al archivo NDJSON de REJECTS_FILE
+func writeRejects(rejects []RejectedItem) error {
+	if len(rejects) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(rejectsPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, reject := range rejects {
+		if err := encoder.Encode(reject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
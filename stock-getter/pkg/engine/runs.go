@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Estados posibles de una ejecución en ingest_runs
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// StartRun registra en ingest_runs el inicio de una ejecución del getter
+func StartRun(ctx context.Context, db *pgxpool.Pool, command string) (uuid.UUID, error) {
+	id := uuid.New()
+	_, err := db.Exec(ctx, "INSERT INTO ingest_runs (id, command, started_at, status) VALUES ($1, $2, $3, $4)", id, command, time.Now(), RunRunning)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error registering run: %w", err)
+	}
+	return id, nil
+}
+
+// FinishRun guarda las estadísticas finales y el estado de la ejecución; runErr nil significa éxito
+func FinishRun(ctx context.Context, db *pgxpool.Pool, id uuid.UUID, pages int, items int, result UpsertResult, runErr error) error {
+	status := RunSucceeded
+	var errorText *string
+	if runErr != nil {
+		status = RunFailed
+		message := runErr.Error()
+		errorText = &message
+	}
+
+	_, err := db.Exec(ctx, `UPDATE ingest_runs SET
+			finished_at = $2, pages = $3, items = $4, inserted = $5, updated = $6, unchanged = $7, rejected = $8, unknown = $9, status = $10, error = $11
+		WHERE id = $1`,
+		id, time.Now(), pages, items, result.Inserted, result.Updated, result.Unchanged, result.Rejected, result.Unknown, status, errorText)
+	if err != nil {
+		return fmt.Errorf("error finishing run: %w", err)
+	}
+	return nil
+}
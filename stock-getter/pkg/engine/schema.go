@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"context"
+
+	"stock/common/migrations"
+)
+
+// checkSchema evita escribir sobre una base que no tiene las migraciones de este binario
+func checkSchema(ctx context.Context, db migrations.DB) error {
+	return migrations.CheckVersion(ctx, db, migrations.Latest())
+}
+
+// MigrateUp aplica las migraciones pendientes hasta target (0 = todas)
+func MigrateUp(ctx context.Context, target int) ([]migrations.Migration, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close(ctx)
+	return migrations.Up(ctx, db, target)
+}
+
+// MigrateDown revierte las últimas steps migraciones
+func MigrateDown(ctx context.Context, steps int) ([]migrations.Migration, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close(ctx)
+	return migrations.Down(ctx, db, steps)
+}
+
+// MigrationStatus lista las migraciones y cuáles están aplicadas
+func MigrationStatus(ctx context.Context) ([]migrations.MigrationStatus, error) {
+	db, err := connectToDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close(ctx)
+	return migrations.Status(ctx, db)
+}
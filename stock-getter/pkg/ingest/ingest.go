@@ -0,0 +1,191 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"stock/getter/pkg/engine"
+	"stock/getter/pkg/source"
+	"stock/getter/pkg/upstream"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Stats acumula lo ocurrido durante una ejecución
+type Stats struct {
+	Runs  int
+	Pages int
+	Items int
+	engine.UpsertResult
+}
+
+func (s *Stats) addPage(items int, result engine.UpsertResult) {
+	s.Pages++
+	s.Items += items
+	s.Add(result)
+}
+
+// Merge suma las estadísticas de otra ejecución
+func (s *Stats) Merge(other Stats) {
+	s.Runs += other.Runs
+	s.Pages += other.Pages
+	s.Items += other.Items
+	s.Add(other.UpsertResult)
+}
+
+// Run es una ejecución registrada en ingest_runs junto con sus estadísticas.
+// Mantiene abierto el pool de la base hasta Finish para no conectarse en cada página.
+type Run struct {
+	ID      uuid.UUID
+	Command string
+	Stats
+	db *pgxpool.Pool
+}
+
+// Start se conecta a la base y registra el inicio de una ejecución del comando indicado
+func Start(ctx context.Context, command string) (*Run, error) {
+	db, err := engine.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := engine.StartRun(ctx, db, command)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error registrando la ejecución: %w", err)
+	}
+	return &Run{ID: id, Command: command, db: db}, nil
+}
+
+// Finish cierra el registro de la ejecución con sus estadísticas y el error final (nil si terminó bien)
+// y libera la conexión
+func (r *Run) Finish(runErr error) error {
+	defer r.db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return engine.FinishRun(ctx, r.db, r.ID, r.Pages, r.Items, r.UpsertResult, runErr)
+}
+
+// Print muestra el resumen de la ejecución
+func (s Stats) Print(elapsed time.Duration) {
+	fmt.Println("Total de ejecuciones:", s.Runs)
+	fmt.Println("Total de páginas procesadas:", s.Pages)
+	fmt.Println("Total de items recibidos:", s.Items)
+	fmt.Println("Total de items insertados:", s.Inserted)
+	fmt.Println("Total de items actualizados:", s.Updated)
+	fmt.Println("Total de items sin cambios:", s.Unchanged)
+	fmt.Println("Total de items rechazados:", s.Rejected)
+	fmt.Println("Total de items con action o rating desconocido:", s.Unknown)
+	fmt.Println("Tiempo total de ejecución:", elapsed)
+}
+
+// consume lee los lotes de src y los guarda hasta agotar la fuente.
+// afterBatch se ejecuta después de guardar cada lote; si devuelve stop=true se termina antes.
+func consume(ctx context.Context, src source.Source, run *Run, filter func([]engine.StockItem) ([]engine.StockItem, bool), afterBatch func(source.Batch) error) error {
+	for {
+		batch, err := src.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		items := batch.Items
+		stop := false
+		if filter != nil {
+			items, stop = filter(batch.Items)
+		}
+
+		result, err := engine.InsertStocks(run.db, run.ID, items)
+		if err != nil {
+			return fmt.Errorf("error guardando lote: %w", err)
+		}
+		run.addPage(len(batch.Items), result)
+
+		if afterBatch != nil {
+			if err := afterBatch(batch); err != nil {
+				return err
+			}
+		}
+		if stop {
+			return nil
+		}
+	}
+}
+
+// Download recorre todas las páginas de la API desde nextPage hasta que deja de devolver next_page,
+// guardando en el checkpoint el token de la siguiente página después de cada página guardada
+func Download(ctx context.Context, client *upstream.Client, nextPage string, run *Run) error {
+	src := source.NewHTTP(client, nextPage)
+	err := consume(ctx, src, run, nil, func(batch source.Batch) error {
+		if batch.Cursor == "" {
+			return nil
+		}
+		// Solo se avanza el checkpoint cuando la página quedó guardada
+		if err := engine.SaveCheckpoint(batch.Cursor); err != nil {
+			return fmt.Errorf("error guardando checkpoint: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// El checkpoint queda en la última página guardada, la siguiente ejecución reanuda desde ahí
+		return fmt.Errorf("descarga detenida en la página %q: %w", src.Page(), err)
+	}
+
+	if err := engine.ClearCheckpoint(); err != nil {
+		return fmt.Errorf("error eliminando checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Sync recorre la API desde la primera página y se detiene en la página donde aparecen
+// items anteriores al record_time más reciente ya guardado. Los items con record_time
+// igual a la marca se vuelven a enviar porque el upsert los deja sin cambios.
+func Sync(ctx context.Context, client *upstream.Client, run *Run) error {
+	watermark, found, err := engine.LatestRecordTime(ctx, run.db)
+	if err != nil {
+		return fmt.Errorf("error consultando el último record_time: %w", err)
+	}
+
+	var filter func([]engine.StockItem) ([]engine.StockItem, bool)
+	if !found {
+		fmt.Println("La tabla stocks está vacía, se recorrerán todas las páginas")
+	} else {
+		fmt.Println("Sincronizando desde:", watermark.Format(time.RFC3339Nano))
+		filter = func(items []engine.StockItem) ([]engine.StockItem, bool) {
+			return newerThan(items, watermark)
+		}
+	}
+
+	src := source.NewHTTP(client, "")
+	if err := consume(ctx, src, run, filter, nil); err != nil {
+		return fmt.Errorf("sync detenido en la página %q: %w", src.Page(), err)
+	}
+	return nil
+}
+
+// Import guarda todos los lotes de una fuente cualquiera (archivos locales, fixtures, etc.)
+func Import(ctx context.Context, src source.Source, run *Run) error {
+	return consume(ctx, src, run, nil, nil)
+}
+
+// newerThan filtra los items con record_time >= watermark e indica si la página
+// ya contenía items más antiguos. Los items con fecha inválida se conservan para
+// que terminen en el archivo de rechazados.
+func newerThan(items []engine.StockItem, watermark time.Time) ([]engine.StockItem, bool) {
+	fresh := make([]engine.StockItem, 0, len(items))
+	reached := false
+	for _, item := range items {
+		recordTime, err := time.Parse(time.RFC3339Nano, item.Time)
+		if err == nil && recordTime.Before(watermark) {
+			reached = true
+			continue
+		}
+		fresh = append(fresh, item)
+	}
+	return fresh, reached
+}
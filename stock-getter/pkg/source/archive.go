@@ -0,0 +1,50 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"stock/getter/pkg/archive"
+	"stock/getter/pkg/engine"
+)
+
+// Archive vuelve a entregar las páginas guardadas por archive.Archive, en el orden en que se consultaron
+type Archive struct {
+	entries []archive.Entry
+}
+
+func NewArchive(a *archive.Archive, since time.Time) (*Archive, error) {
+	entries, err := a.List(since)
+	if err != nil {
+		return nil, err
+	}
+	return &Archive{entries: entries}, nil
+}
+
+func (s *Archive) Next(ctx context.Context) (Batch, error) {
+	if len(s.entries) == 0 {
+		return Batch{}, io.EOF
+	}
+	entry := s.entries[0]
+	s.entries = s.entries[1:]
+
+	body, err := archive.Read(entry)
+	if err != nil {
+		return Batch{}, err
+	}
+	var stockResponse engine.StockResponse
+	if err := json.Unmarshal(body, &stockResponse); err != nil {
+		return Batch{}, fmt.Errorf("%s: %w", entry.Path, err)
+	}
+
+	fmt.Printf("Reprocesando página %q consultada el %s\n", entry.PageToken, entry.FetchedAt.Format(time.RFC3339))
+	return Batch{Items: stockResponse.Items, Cursor: stockResponse.NextPage}, nil
+}
+
+// Len es la cantidad de páginas pendientes por reprocesar
+func (s *Archive) Len() int {
+	return len(s.entries)
+}
@@ -0,0 +1,173 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"stock/getter/pkg/engine"
+)
+
+// JSONFile lee un archivo con un arreglo de items o con el mismo formato de respuesta de la API
+// ({"items": [...], "next_page": "..."}) y entrega los items en lotes de batchSize
+type JSONFile struct {
+	items     []engine.StockItem
+	batchSize int
+}
+
+func NewJSONFile(path string, batchSize int) (*JSONFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []engine.StockItem
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &items)
+	} else {
+		var stockResponse engine.StockResponse
+		err = json.Unmarshal(trimmed, &stockResponse)
+		items = stockResponse.Items
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", path, err)
+	}
+
+	return &JSONFile{items: items, batchSize: batchSize}, nil
+}
+
+func (s *JSONFile) Next(ctx context.Context) (Batch, error) {
+	if len(s.items) == 0 {
+		return Batch{}, io.EOF
+	}
+	size := min(s.batchSize, len(s.items))
+	batch := Batch{Items: s.items[:size]}
+	s.items = s.items[size:]
+	return batch, nil
+}
+
+// NDJSONFile lee un item por línea y los entrega en lotes de batchSize
+type NDJSONFile struct {
+	file      *os.File
+	scanner   *bufio.Scanner
+	batchSize int
+	line      int
+}
+
+func NewNDJSONFile(path string, batchSize int) (*NDJSONFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	return &NDJSONFile{file: file, scanner: scanner, batchSize: batchSize}, nil
+}
+
+func (s *NDJSONFile) Next(ctx context.Context) (Batch, error) {
+	var batch Batch
+	for len(batch.Items) < s.batchSize && s.scanner.Scan() {
+		s.line++
+		line := bytes.TrimSpace(s.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var item engine.StockItem
+		if err := json.Unmarshal(line, &item); err != nil {
+			return Batch{}, fmt.Errorf("línea %d: %w", s.line, err)
+		}
+		batch.Items = append(batch.Items, item)
+	}
+	if err := s.scanner.Err(); err != nil {
+		return Batch{}, err
+	}
+	if len(batch.Items) == 0 {
+		s.file.Close()
+		return Batch{}, io.EOF
+	}
+	return batch, nil
+}
+
+// CSVFile lee un CSV con encabezado usando los mismos nombres de columna que la API
+// (ticker, target_from, target_to, company, action, brokerage, rating_from, rating_to, time).
+// Las columnas desconocidas se ignoran.
+type CSVFile struct {
+	file      *os.File
+	reader    *csv.Reader
+	columns   map[string]int
+	batchSize int
+}
+
+func NewCSVFile(path string, batchSize int) (*CSVFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error leyendo encabezado de %s: %w", path, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["ticker"]; !ok {
+		file.Close()
+		return nil, fmt.Errorf("el encabezado de %s no tiene la columna ticker", path)
+	}
+
+	return &CSVFile{file: file, reader: reader, columns: columns, batchSize: batchSize}, nil
+}
+
+func (s *CSVFile) Next(ctx context.Context) (Batch, error) {
+	var batch Batch
+	for len(batch.Items) < s.batchSize {
+		record, err := s.reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Batch{}, err
+		}
+		batch.Items = append(batch.Items, s.item(record))
+	}
+	if len(batch.Items) == 0 {
+		s.file.Close()
+		return Batch{}, io.EOF
+	}
+	return batch, nil
+}
+
+func (s *CSVFile) item(record []string) engine.StockItem {
+	field := func(name string) string {
+		i, ok := s.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	return engine.StockItem{
+		Ticker:     field("ticker"),
+		TargetFrom: field("target_from"),
+		TargetTo:   field("target_to"),
+		Company:    field("company"),
+		Action:     field("action"),
+		Brokerage:  field("brokerage"),
+		RatingFrom: field("rating_from"),
+		RatingTo:   field("rating_to"),
+		Time:       field("time"),
+	}
+}
@@ -0,0 +1,138 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"stock/getter/pkg/engine"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// readBatches lee la fuente hasta EOF y devuelve los tickers de cada lote
+func readBatches(t *testing.T, source Source) [][]string {
+	t.Helper()
+	var batches [][]string
+	for {
+		batch, err := source.Next(context.Background())
+		if errors.Is(err, io.EOF) {
+			return batches
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tickers []string
+		for _, item := range batch.Items {
+			tickers = append(tickers, item.Ticker)
+		}
+		batches = append(batches, tickers)
+	}
+}
+
+func TestJSONFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"arreglo", `[{"ticker":"AAPL"},{"ticker":"MSFT"},{"ticker":"ETR"}]`},
+		{"respuesta de la API", `  {"items":[{"ticker":"AAPL"},{"ticker":"MSFT"},{"ticker":"ETR"}],"next_page":"ETR"}`},
+	}
+	for _, test := range tests {
+		source, err := NewJSONFile(writeFile(t, "stocks.json", test.content), 2)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		want := [][]string{{"AAPL", "MSFT"}, {"ETR"}}
+		if got := readBatches(t, source); !slices.EqualFunc(got, want, slices.Equal) {
+			t.Errorf("%s: lotes = %v, want %v", test.name, got, want)
+		}
+	}
+
+	if _, err := NewJSONFile(writeFile(t, "stocks.json", `[{"ticker":`), 2); err == nil || !strings.Contains(err.Error(), "stocks.json") {
+		t.Errorf("JSON inválido: err = %v, want error con el archivo", err)
+	}
+}
+
+func TestNDJSONFile(t *testing.T) {
+	content := `{"ticker":"AAPL","target_to":"$200.00"}
+
+{"ticker":"MSFT"}
+{"ticker":"ETR"}
+`
+	source, err := NewNDJSONFile(writeFile(t, "stocks.ndjson", content), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"AAPL", "MSFT"}, {"ETR"}}
+	if got := readBatches(t, source); !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("lotes = %v, want %v", got, want)
+	}
+}
+
+func TestNDJSONFileReportsBadLine(t *testing.T) {
+	content := "{\"ticker\":\"AAPL\"}\n\n{\"ticker\":\n{\"ticker\":\"ETR\"}\n"
+	source, err := NewNDJSONFile(writeFile(t, "stocks.ndjson", content), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = source.Next(context.Background())
+	if err == nil || !strings.HasPrefix(err.Error(), "línea 3:") {
+		t.Errorf("err = %v, want error en la línea 3", err)
+	}
+}
+
+func TestCSVFile(t *testing.T) {
+	content := "\ufeffTicker, Target_To,extra,time,rating_to\n" +
+		"AAPL,$200.00,x,2025-07-17T00:30:07Z,Buy\n" +
+		"MSFT,$410.00,y,2025-07-18T00:30:07Z,Hold\n" +
+		"ETR,$88.00\n"
+	source, err := NewCSVFile(writeFile(t, "stocks.csv", content), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch, err := source.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := engine.StockItem{Ticker: "AAPL", TargetTo: "$200.00", Time: "2025-07-17T00:30:07Z", RatingTo: "Buy"}
+	if len(batch.Items) != 2 || batch.Items[0] != want {
+		t.Errorf("primer lote = %+v, want %d items empezando por %+v", batch.Items, 2, want)
+	}
+
+	// Las filas con menos columnas dejan vacíos los campos faltantes para que la validación las rechace
+	batch, err = source.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = engine.StockItem{Ticker: "ETR", TargetTo: "$88.00"}
+	if len(batch.Items) != 1 || batch.Items[0] != want {
+		t.Errorf("segundo lote = %+v, want [%+v]", batch.Items, want)
+	}
+
+	if _, err := source.Next(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("err = %v, want EOF", err)
+	}
+}
+
+func TestCSVFileRequiresTicker(t *testing.T) {
+	if _, err := NewCSVFile(writeFile(t, "stocks.csv", "symbol,target_to\nAAPL,$200.00\n"), 10); err == nil {
+		t.Error("se esperaba error sin la columna ticker")
+	}
+	if _, err := NewCSVFile(writeFile(t, "stocks.csv", ""), 10); err == nil {
+		t.Error("se esperaba error con el archivo vacío")
+	}
+}
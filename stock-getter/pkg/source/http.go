@@ -0,0 +1,39 @@
+package source
+
+import (
+	"context"
+	"io"
+
+	"stock/getter/pkg/upstream"
+)
+
+// HTTP recorre la API paginada de STOCKS_URL; cada página es un lote
+type HTTP struct {
+	client   *upstream.Client
+	nextPage string
+	done     bool
+}
+
+func NewHTTP(client *upstream.Client, nextPage string) *HTTP {
+	return &HTTP{client: client, nextPage: nextPage}
+}
+
+func (s *HTTP) Next(ctx context.Context) (Batch, error) {
+	if s.done {
+		return Batch{}, io.EOF
+	}
+
+	stockResponse, err := s.client.GetStocks(ctx, s.nextPage)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	s.nextPage = stockResponse.NextPage
+	s.done = s.nextPage == ""
+	return Batch{Items: stockResponse.Items, Cursor: s.nextPage}, nil
+}
+
+// Page es el token de la página que se consultará en el siguiente Next
+func (s *HTTP) Page() string {
+	return s.nextPage
+}
@@ -0,0 +1,62 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"stock/getter/pkg/engine"
+)
+
+// Batch es un grupo de items que se guarda en una sola transacción
+type Batch struct {
+	Items []engine.StockItem
+	// Cursor identifica la posición siguiente a este lote (next_page en la API).
+	// Vacío cuando la fuente no soporta reanudar o ya no hay más lotes.
+	Cursor string
+}
+
+// Source entrega los items a ingerir por lotes; Next devuelve io.EOF cuando no quedan más
+type Source interface {
+	Next(ctx context.Context) (Batch, error)
+}
+
+// Formatos de archivo soportados por Open
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// Open abre un archivo local como Source. Si format está vacío se deduce de la extensión.
+func Open(path string, format string, batchSize int) (Source, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("el tamaño de lote debe ser mayor a 0, se recibió %d", batchSize)
+	}
+	if format == "" {
+		format = formatFromExtension(path)
+	}
+	switch format {
+	case FormatJSON:
+		return NewJSONFile(path, batchSize)
+	case FormatNDJSON:
+		return NewNDJSONFile(path, batchSize)
+	case FormatCSV:
+		return NewCSVFile(path, batchSize)
+	default:
+		return nil, fmt.Errorf("formato no soportado %q (usa json, ndjson o csv)", format)
+	}
+}
+
+func formatFromExtension(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	case ".csv":
+		return FormatCSV
+	}
+	return ""
+}
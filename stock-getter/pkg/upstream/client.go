@@ -0,0 +1,243 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"stock/getter/pkg/archive"
+	"stock/getter/pkg/config"
+	"stock/getter/pkg/engine"
+)
+
+type Config struct {
+	URL               string
+	Token             string
+	Timeout           time.Duration
+	MaxRetries        int
+	BaseBackoff       time.Duration
+	MaxBackoff        time.Duration
+	RequestsPerSecond float64
+	// Archive recibe el cuerpo crudo de cada página antes de parsearlo; nil para no archivar
+	Archive *archive.Archive
+}
+
+// ConfigFromEnv arma la configuración del cliente.
+//
+// Variables de entorno:
+//   - STOCKS_URL, API_TOKEN: endpoint y token Bearer de la API
+//   - STOCKS_TIMEOUT: timeout por petición (por defecto 30s)
+//   - STOCKS_MAX_RETRIES: reintentos ante 429, 5xx o fallas de red (por defecto 5; 0 desactiva los reintentos)
+//   - STOCKS_BACKOFF, STOCKS_MAX_BACKOFF: espera base y máxima entre reintentos (por defecto 500ms y 30s)
+//   - STOCKS_RPS: máximo de peticiones por segundo (por defecto 5)
+//   - RAW_ARCHIVE_DIR: carpeta donde se archivan las páginas crudas (ver archive.FromEnv)
+func ConfigFromEnv() Config {
+	return Config{
+		URL:               os.Getenv("STOCKS_URL"),
+		Token:             os.Getenv("API_TOKEN"),
+		Timeout:           config.Duration("STOCKS_TIMEOUT", 30*time.Second),
+		MaxRetries:        config.IntAtLeast("STOCKS_MAX_RETRIES", 5, 0),
+		BaseBackoff:       config.Duration("STOCKS_BACKOFF", 500*time.Millisecond),
+		MaxBackoff:        config.Duration("STOCKS_MAX_BACKOFF", 30*time.Second),
+		RequestsPerSecond: config.Float("STOCKS_RPS", 5),
+		Archive:           archive.FromEnv(),
+	}
+}
+
+type Client struct {
+	config     Config
+	httpClient *http.Client
+	limiter    *rateLimiter
+}
+
+func NewClient(config Config) *Client {
+	return &Client{
+		config:     config,
+		httpClient: &http.Client{Timeout: config.Timeout},
+		limiter:    newRateLimiter(config.RequestsPerSecond),
+	}
+}
+
+/*
+*
+* Get stock list from api
+
+Query Params:
+- next_page: next page token
+
+Headers:
+- Authorization: Bearer <token>
+- Content-Type: application/json
+
+Response Item:
+
+	{
+		"ticker": "ETR",
+		"target_from": "$85.00",
+		"target_to": "$88.00",
+		"company": "Entergy",
+		"action": "target raised by",
+		"brokerage": "KeyCorp",
+		"rating_from": "Overweight",
+		"rating_to": "Overweight",
+		"time": "2025-07-17T00:30:07.155596923Z"
+	}
+
+Reintenta con backoff exponencial y jitter ante 429, 5xx y fallas de red,
+respetando Retry-After cuando viene en la respuesta.
+
+* @param nextPage: next page token
+* @return StockResponse
+*/
+func (c *Client) GetStocks(ctx context.Context, nextPage string) (engine.StockResponse, error) {
+
+	pageURL, err := c.pageURL(nextPage)
+	if err != nil {
+		return engine.StockResponse{}, err
+	}
+
+	var lastErr error
+	attempts := c.config.MaxRetries + 1
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			wait := c.backoff(attempt, lastErr)
+			fmt.Printf("Reintentando en %s (intento %d de %d): %v\n", wait, attempt+1, attempts, lastErr)
+			if err := sleep(ctx, wait); err != nil {
+				return engine.StockResponse{}, err
+			}
+		}
+
+		if err := c.limiter.Wait(ctx); err != nil {
+			return engine.StockResponse{}, err
+		}
+
+		stockResponse, err := c.fetch(ctx, nextPage, pageURL)
+		if err == nil {
+			return stockResponse, nil
+		}
+		if !retryable(err) {
+			return engine.StockResponse{}, err
+		}
+		lastErr = err
+	}
+
+	return engine.StockResponse{}, &RetriesExhaustedError{Attempts: attempts, Err: lastErr}
+}
+
+func (c *Client) pageURL(nextPage string) (string, error) {
+	if c.config.URL == "" {
+		return "", errors.New("STOCKS_URL no está configurada")
+	}
+	if nextPage == "" {
+		return c.config.URL, nil
+	}
+	parsed, err := url.Parse(c.config.URL)
+	if err != nil {
+		return "", fmt.Errorf("STOCKS_URL inválida: %w", err)
+	}
+	query := parsed.Query()
+	query.Set("next_page", nextPage)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+func (c *Client) fetch(ctx context.Context, nextPage string, pageURL string) (engine.StockResponse, error) {
+	fmt.Println("Consultando a:", pageURL)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return engine.StockResponse{}, err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.config.Token)
+	request.Header.Set("Content-Type", "application/json")
+
+	fetchedAt := time.Now()
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return engine.StockResponse{}, &RequestError{URL: pageURL, Err: err}
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return engine.StockResponse{}, &RequestError{URL: pageURL, Err: err}
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return engine.StockResponse{}, &StatusError{
+			StatusCode: response.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After")),
+		}
+	}
+
+	// Se archiva antes de parsear para poder reprocesar la página si el parseo cambia
+	if c.config.Archive != nil {
+		if err := c.config.Archive.Save(nextPage, fetchedAt, body); err != nil {
+			return engine.StockResponse{}, fmt.Errorf("error archivando la página: %w", err)
+		}
+	}
+
+	var stockResponse engine.StockResponse
+	if err := json.Unmarshal(body, &stockResponse); err != nil {
+		return engine.StockResponse{}, &DecodeError{Body: string(body), Err: err}
+	}
+
+	return stockResponse, nil
+}
+
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	var requestErr *RequestError
+	return errors.As(err, &requestErr)
+}
+
+// backoff calcula la espera antes del intento indicado: Retry-After si el servidor lo envió,
+// si no base * 2^(intento-1) con jitter completo. Ambas quedan limitadas por MaxBackoff.
+func (c *Client) backoff(attempt int, lastErr error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > 0 {
+		return min(statusErr.RetryAfter, c.config.MaxBackoff)
+	}
+
+	wait := c.config.BaseBackoff << (attempt - 1)
+	if wait <= 0 || wait > c.config.MaxBackoff {
+		wait = c.config.MaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(wait)) + 1)
+}
+
+// parseRetryAfter soporta tanto segundos ("120") como fecha HTTP; una fecha pasada devuelve 0
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	client := NewClient(Config{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+
+	tests := []struct {
+		name    string
+		attempt int
+		lastErr error
+		max     time.Duration
+		exact   bool
+	}{
+		{"primer reintento", 1, errors.New("falla"), 100 * time.Millisecond, false},
+		{"tercer reintento", 3, errors.New("falla"), 400 * time.Millisecond, false},
+		{"limitado por MaxBackoff", 10, errors.New("falla"), time.Second, false},
+		{"sin desbordar", 80, errors.New("falla"), time.Second, false},
+		{"Retry-After", 1, &StatusError{StatusCode: 429, RetryAfter: 300 * time.Millisecond}, 300 * time.Millisecond, true},
+		{"Retry-After mayor que MaxBackoff", 1, &StatusError{StatusCode: 429, RetryAfter: time.Hour}, time.Second, true},
+		{"Retry-After envuelto", 2, fmt.Errorf("página: %w", &StatusError{StatusCode: 503, RetryAfter: 2 * time.Second}), time.Second, true},
+	}
+	for _, test := range tests {
+		for range 20 {
+			wait := client.backoff(test.attempt, test.lastErr)
+			if test.exact && wait != test.max {
+				t.Errorf("%s: backoff = %s, want %s", test.name, wait, test.max)
+				break
+			}
+			if wait <= 0 || wait > test.max {
+				t.Errorf("%s: backoff = %s, want entre 0 y %s", test.name, wait, test.max)
+				break
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("120"); got != 120*time.Second {
+		t.Errorf("segundos: %s, want 2m0s", got)
+	}
+	for _, value := range []string{"", "0", "-5", "pronto"} {
+		if got := parseRetryAfter(value); got != 0 {
+			t.Errorf("parseRetryAfter(%q) = %s, want 0", value, got)
+		}
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("fecha futura: %s, want cerca de 1m", got)
+	}
+	past := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(past); got != 0 {
+		t.Errorf("fecha pasada: %s, want 0", got)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		status int
+		want   bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "7")
+			http.Error(w, "error", test.status)
+		}))
+		client := NewClient(Config{URL: server.URL, Timeout: time.Second})
+
+		_, err := client.fetch(context.Background(), "", server.URL)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != test.status || statusErr.RetryAfter != 7*time.Second {
+			t.Errorf("%d: err = %v", test.status, err)
+		}
+		if got := retryable(err); got != test.want {
+			t.Errorf("%d: retryable = %v, want %v", test.status, got, test.want)
+		}
+		server.Close()
+	}
+
+	// Una falla de red se reintenta; un cuerpo inválido no
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	_, err := NewClient(Config{URL: server.URL, Timeout: time.Second}).fetch(context.Background(), "", server.URL)
+	if !retryable(err) {
+		t.Errorf("falla de red: retryable = false (%v)", err)
+	}
+	if retryable(&DecodeError{Err: errors.New("json")}) {
+		t.Error("DecodeError: retryable = true")
+	}
+}
+
+func TestGetStocksRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"items":[{"ticker":"AAPL"}],"next_page":"AAPL"}`)
+	}))
+	defer server.Close()
+
+	config := Config{URL: server.URL, Timeout: time.Second, MaxRetries: 2, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+	response, err := NewClient(config).GetStocks(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Items) != 1 || response.NextPage != "AAPL" || requests.Load() != 2 {
+		t.Errorf("response = %+v después de %d peticiones", response, requests.Load())
+	}
+}
+
+func TestGetStocksWithoutRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "error", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	config := Config{URL: server.URL, Timeout: time.Second, MaxRetries: 0, BaseBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	_, err := NewClient(config).GetStocks(context.Background(), "")
+	var exhausted *RetriesExhaustedError
+	if !errors.As(err, &exhausted) || exhausted.Attempts != 1 || requests.Load() != 1 {
+		t.Errorf("err = %v después de %d peticiones, want RetriesExhaustedError con 1 intento", err, requests.Load())
+	}
+}
+
+func TestMaxRetriesFromEnv(t *testing.T) {
+	t.Setenv("STOCKS_MAX_RETRIES", "0")
+	if got := ConfigFromEnv().MaxRetries; got != 0 {
+		t.Errorf("STOCKS_MAX_RETRIES=0: MaxRetries = %d", got)
+	}
+	t.Setenv("STOCKS_MAX_RETRIES", "-1")
+	if got := ConfigFromEnv().MaxRetries; got != 5 {
+		t.Errorf("STOCKS_MAX_RETRIES=-1: MaxRetries = %d, want 5", got)
+	}
+}
@@ -0,0 +1,66 @@
+package upstream
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StatusError se devuelve cuando la API responde con un código distinto de 2xx
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter es la espera pedida por el servidor en el header Retry-After (0 si no vino)
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("upstream respondió %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// Retryable indica si vale la pena volver a intentar la petición
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// DecodeError se devuelve cuando el cuerpo de la respuesta no es el JSON esperado
+type DecodeError struct {
+	Body string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("respuesta inválida de upstream: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// RequestError envuelve fallas de red (conexión rechazada, timeout, etc.)
+type RequestError struct {
+	URL string
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("error consultando %s: %v", e.URL, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// RetriesExhaustedError indica que se agotaron los reintentos; Err es el último error obtenido
+type RetriesExhaustedError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	return fmt.Sprintf("se agotaron los reintentos (%d intentos): %v", e.Attempts, e.Err)
+}
+
+func (e *RetriesExhaustedError) Unwrap() error {
+	return e.Err
+}
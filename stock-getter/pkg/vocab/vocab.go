@@ -0,0 +1,112 @@
+package vocab
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Acciones canónicas
+const (
+	ActionUpgrade     = "upgrade"
+	ActionDowngrade   = "downgrade"
+	ActionInitiate    = "initiate"
+	ActionReiterate   = "reiterate"
+	ActionTargetRaise = "target_raise"
+	ActionTargetLower = "target_lower"
+)
+
+// Escala ordinal de ratings: 1 = strong sell ... 5 = strong buy
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+var actions = []string{ActionUpgrade, ActionDowngrade, ActionInitiate, ActionReiterate, ActionTargetRaise, ActionTargetLower}
+
+//go:embed default.json
+var defaultVocabulary []byte
+
+// Vocabulary traduce el texto libre de la API a valores canónicos.
+// Las llaves se comparan normalizadas: minúsculas, sin espacios extra y con "-"/"_" como espacio.
+type Vocabulary struct {
+	Actions map[string]string `json:"actions"`
+	Ratings map[string]int    `json:"ratings"`
+}
+
+var current = sync.OnceValues(load)
+
+// Current devuelve el vocabulario por defecto, extendido con el archivo de VOCABULARY_FILE si está definido.
+// Las entradas del archivo reemplazan a las por defecto con la misma llave.
+func Current() (*Vocabulary, error) {
+	return current()
+}
+
+func load() (*Vocabulary, error) {
+	vocabulary := &Vocabulary{}
+	if err := vocabulary.merge(defaultVocabulary, "vocabulario por defecto"); err != nil {
+		return nil, err
+	}
+
+	path := os.Getenv("VOCABULARY_FILE")
+	if path == "" {
+		return vocabulary, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo VOCABULARY_FILE: %w", err)
+	}
+	if err := vocabulary.merge(content, path); err != nil {
+		return nil, err
+	}
+	return vocabulary, nil
+}
+
+func (v *Vocabulary) merge(content []byte, origin string) error {
+	var extra Vocabulary
+	if err := json.Unmarshal(content, &extra); err != nil {
+		return fmt.Errorf("%s: %w", origin, err)
+	}
+
+	if v.Actions == nil {
+		v.Actions = make(map[string]string)
+	}
+	if v.Ratings == nil {
+		v.Ratings = make(map[string]int)
+	}
+	for text, action := range extra.Actions {
+		if !slices.Contains(actions, action) {
+			return fmt.Errorf("%s: acción desconocida %q para %q (usa %s)", origin, action, text, strings.Join(actions, ", "))
+		}
+		v.Actions[normalize(text)] = action
+	}
+	for text, rating := range extra.Ratings {
+		if rating < MinRating || rating > MaxRating {
+			return fmt.Errorf("%s: rating %d fuera de escala para %q (%d a %d)", origin, rating, text, MinRating, MaxRating)
+		}
+		v.Ratings[normalize(text)] = rating
+	}
+	return nil
+}
+
+// Action devuelve la acción canónica; ok es false si el texto no está en el vocabulario
+func (v *Vocabulary) Action(text string) (action string, ok bool) {
+	action, ok = v.Actions[normalize(text)]
+	return action, ok
+}
+
+// Rating devuelve el valor en la escala 1..5; ok es false si el texto no está en el vocabulario
+func (v *Vocabulary) Rating(text string) (rating int, ok bool) {
+	rating, ok = v.Ratings[normalize(text)]
+	return rating, ok
+}
+
+func normalize(text string) string {
+	text = strings.ToLower(text)
+	text = strings.NewReplacer("-", " ", "_", " ").Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}